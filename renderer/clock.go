@@ -0,0 +1,53 @@
+package renderer
+
+import (
+	"crypto/rand"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/VirtusLab/go-extended/pkg/renderer/config"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// TimeFunctions provides the 'now', 'date' and 'uuidv4' template functions using the given clock
+// and random source, e.g. to make the rendering reproducible in tests, see RenderWithClock
+func TimeFunctions(clock func() time.Time, random io.Reader) template.FuncMap {
+	sprigDate := sprig.TxtFuncMap()["date"].(func(string, interface{}) string)
+	return template.FuncMap{
+		"now": clock,
+		// date formats a time with a Go layout, e.g. '{{ now | date "2006-01-02" }}'
+		"date": func(layout string, t interface{}) string {
+			return sprigDate(layout, t)
+		},
+		"uuidv4": func() (string, error) {
+			id, err := uuid.NewRandomFromReader(random)
+			if err != nil {
+				return "", errors.Wrap(err, "can't generate a UUID")
+			}
+			return id.String(), nil
+		},
+	}
+}
+
+// WithTimeFunctions mutates Renderer configuration by merging the time and UUID template functions,
+// see TimeFunctions
+func WithTimeFunctions(clock func() time.Time, random io.Reader) func(*config.Config) {
+	return WithMoreFunctions(TimeFunctions(clock, random))
+}
+
+// RenderWithClock renders the template like RenderTemplate, but the 'now', 'date' and 'uuidv4'
+// template functions use the given clock and random source
+func RenderWithClock(tmpl string, params parameters.Parameters, clock func() time.Time, random io.Reader) (string, error) {
+	configurators := append(defaultConfigurators(params), WithTimeFunctions(clock, random))
+	return New(configurators...).Render(tmpl)
+}
+
+// systemTimeFunctions are the time functions using the system clock and the cryptographic random source
+func systemTimeFunctions() func(*config.Config) {
+	return WithTimeFunctions(time.Now, rand.Reader)
+}
@@ -3,6 +3,7 @@ package renderer
 import (
 	"bytes"
 	"compress/gzip"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
 	"math/big"
@@ -10,7 +11,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/VirtusLab/render/renderer/parameters"
 	"github.com/apparentlymart/go-cidr/cidr"
@@ -18,6 +21,7 @@ import (
 	"github.com/VirtusLab/go-extended/pkg/files"
 	json2 "github.com/VirtusLab/go-extended/pkg/json"
 	"github.com/VirtusLab/go-extended/pkg/jsonpath"
+	base "github.com/VirtusLab/go-extended/pkg/renderer"
 	yaml2 "github.com/VirtusLab/go-extended/pkg/yaml"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -32,10 +36,17 @@ func (r *renderer) root() (string, error) {
 	return files.Pwd()
 }
 
+// MaxRenderDepth is the maximum nesting of the template files rendered by the 'render' template function,
+// e.g. to stop the templates including each other
+var MaxRenderDepth = 10
+
 // NestedRender template function allows for recursive use of the renderer
 // Accepts 1 or 2 arguments:
 // - NestedRender(template string)
 // - NestedRender(extraParams map[string]interface{}, template string)
+// - NestedRender(file string, data interface{}) renders the template file with the data as the dot
+// and returns its output, e.g. '{{ render "other.tmpl" . }}', the relative path is based on the 'root'
+// like in ReadFile, unlike '{{ template }}' the file is parsed on its own, see also MaxRenderDepth
 // Returns an error when 0 or more than 2 arguments are passed.
 func (r *renderer) NestedRender(args ...interface{}) (string, error) {
 	argN := len(args)
@@ -56,6 +67,9 @@ func (r *renderer) NestedRender(args ...interface{}) (string, error) {
 				"expected the only parameter to be a 'string', got: '%T'", args[0])
 		}
 	case 2:
+		if file, ok := args[0].(string); ok {
+			return r.renderInclude(file, args[1])
+		}
 		var ok bool
 		extraParams, ok = args[0].(map[string]interface{})
 		if !ok {
@@ -75,6 +89,80 @@ func (r *renderer) NestedRender(args ...interface{}) (string, error) {
 	).Render(template)
 }
 
+// renderInclude renders the template file with the data, one level deeper than the renderer
+func (r *renderer) renderInclude(file string, data interface{}) (string, error) {
+	if r.depth >= MaxRenderDepth {
+		return "", errors.Errorf("can't render the file '%s', the maximum render depth of %d exceeded", file, MaxRenderDepth)
+	}
+	content, err := r.ReadFile(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't render the file '%s'", file)
+	}
+
+	include := &renderer{
+		Renderer:       base.NewWithConfig(r.Configuration()),
+		depth:          r.depth + 1,
+		checkFunctions: r.checkFunctions,
+	}
+	functions := template.FuncMap{}
+	for name, function := range r.Configuration().ExtraFunctions {
+		functions[name] = function
+	}
+	functions["render"] = include.NestedRender
+	include.Reconfigure(WithFunctions(functions))
+
+	switch data := data.(type) {
+	case parameters.Parameters:
+		include.Reconfigure(WithParameters(data))
+	case map[string]interface{}:
+		include.Reconfigure(WithParameters(data))
+	default:
+		t, err := include.Parse(file, content, include.Configuration().ExtraFunctions)
+		if err != nil {
+			return "", err
+		}
+		var buffer bytes.Buffer
+		err = t.Execute(&buffer, data)
+		if err != nil {
+			return "", errors.Wrapf(err, "can't render the file '%s' with the data of type: '%T'", file, data)
+		}
+		return buffer.String(), nil
+	}
+	return include.NamedRender(file, content)
+}
+
+// KeyValue is a flattened parameter key with its value, see Flatten
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// Flatten is a template function that returns the parameter leaves sorted by the flattened key
+// (see parameters.Parameters.Flatten), optionally only the ones under the given key prefix,
+// e.g. '{{ range flatten "servers" }}{{ .Key }}={{ .Value }}{{ end }}'
+func (r *renderer) Flatten(prefix ...string) ([]KeyValue, error) {
+	if len(prefix) > 1 {
+		return nil, errors.Errorf("expected 0 or 1 parameters, got: %d", len(prefix))
+	}
+	flat := parameters.Parameters(r.Configuration().Parameters).Flatten()
+
+	var result []KeyValue
+	for key, value := range flat {
+		if len(prefix) == 1 && !hasKeyPrefix(key, prefix[0]) {
+			continue
+		}
+		result = append(result, KeyValue{Key: key, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func hasKeyPrefix(key, prefix string) bool {
+	return key == prefix ||
+		strings.HasPrefix(key, prefix+".") ||
+		strings.HasPrefix(key, prefix+"[")
+}
+
 // N returns a slice of integers form the given start to end (inclusive)
 func N(start, end int) []int {
 	var result []int
@@ -84,6 +172,185 @@ func N(start, end int) []int {
 	return result
 }
 
+// Required is a template function that returns the value, or fails the rendering with the message
+// if the value is nil or an empty string, e.g. '{{ required "db.host is required" .db.host }}'
+func Required(message string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, errors.New(message)
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+// StableChoice is a template function that returns an element of the list chosen by the hash of the seed,
+// so the same seed always chooses the same element, e.g. '{{ stableChoice .name (list "shard-a" "shard-b") }}'
+func StableChoice(seed string, list interface{}) (interface{}, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, errors.Errorf("stableChoice expects a list, got type: '%T'", list)
+	}
+	if value.Len() == 0 {
+		return nil, errors.New("stableChoice expects a non-empty list")
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(seed))
+	return value.Index(int(hash.Sum64() % uint64(value.Len()))).Interface(), nil
+}
+
+// NumEq is a template function that returns true if the numbers are equal, comparing them as float64,
+// so e.g. the int 1 and the float64 1 (as decoded from JSON) are equal, unlike with 'eq'
+func NumEq(a, b interface{}) (bool, error) {
+	x, y, err := asFloats("numEq", a, b)
+	return x == y, err
+}
+
+// NumLt is a template function that returns true if the first number is less than the second, see NumEq
+func NumLt(a, b interface{}) (bool, error) {
+	x, y, err := asFloats("numLt", a, b)
+	return x < y, err
+}
+
+// NumGt is a template function that returns true if the first number is greater than the second, see NumEq
+func NumGt(a, b interface{}) (bool, error) {
+	x, y, err := asFloats("numGt", a, b)
+	return x > y, err
+}
+
+// At is a template function that returns the element of a slice at the index or the value of a map at the key,
+// or the default if the index is out of range, the key is missing or the collection is nil,
+// e.g. '{{ at .list 3 "default" }}' or '{{ at .labels "team" "none" }}'
+func At(collection, key, defaultValue interface{}) (interface{}, error) {
+	if collection == nil {
+		return defaultValue, nil
+	}
+	value := reflect.ValueOf(collection)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		index, ok := asFloat(key)
+		if !ok {
+			return nil, errors.Errorf("at expects a number index for a slice, got type: '%T'", key)
+		}
+		if index < 0 || int(index) >= value.Len() {
+			return defaultValue, nil
+		}
+		return value.Index(int(index)).Interface(), nil
+	case reflect.Map:
+		k := reflect.ValueOf(key)
+		if !k.IsValid() || !k.Type().AssignableTo(value.Type().Key()) {
+			return nil, errors.Errorf("at expects a '%s' key for a map, got type: '%T'", value.Type().Key(), key)
+		}
+		element := value.MapIndex(k)
+		if !element.IsValid() {
+			return defaultValue, nil
+		}
+		return element.Interface(), nil
+	default:
+		return nil, errors.Errorf("at expects a slice or a map, got type: '%T'", collection)
+	}
+}
+
+// HasPath is a template function that returns true if the dotted key exists in the map,
+// also if its value is empty or nil, e.g. '{{ if hasPath . "db.host" }}', unlike '{{ if .db.host }}'
+func HasPath(tree interface{}, key string) (bool, error) {
+	m, ok := asParameters(tree)
+	if !ok {
+		return false, errors.Errorf("hasPath expects a map, got type: '%T'", tree)
+	}
+	return m.Exists(key), nil
+}
+
+// TOC is a template function that returns the sorted key hierarchy of the parameters as a nested markdown
+// bullet list, indented by two spaces per level, the slices are leaves, e.g. '{{ toc . }}' is
+// "- db\n  - host\n  - port\n- debug\n", an empty map is an empty string
+func TOC(tree interface{}) (string, error) {
+	m, ok := asParameters(tree)
+	if !ok {
+		return "", errors.Errorf("toc expects a map, got type: '%T'", tree)
+	}
+	var b strings.Builder
+	writeTOC(&b, m, 0)
+	return b.String(), nil
+}
+
+func writeTOC(b *strings.Builder, m parameters.Parameters, depth int) {
+	for _, key := range m.Keys() {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString("- ")
+		b.WriteString(key)
+		b.WriteString("\n")
+		if nested, ok := asParameters(m[key]); ok {
+			writeTOC(b, nested, depth+1)
+		}
+	}
+}
+
+func asParameters(value interface{}) (parameters.Parameters, bool) {
+	switch m := value.(type) {
+	case parameters.Parameters:
+		return m, true
+	case map[string]interface{}:
+		return m, true
+	}
+	return nil, false
+}
+
+// IsFirst is a template function that returns true if the index is the first one of a non-empty collection,
+// e.g. '{{ range $i, $e := .items }}{{ if not (isFirst $i $.items) }}, {{ end }}{{ $e }}{{ end }}'
+func IsFirst(index, collection interface{}) (bool, error) {
+	return positional("isFirst", index, collection, func(index, length int) bool { return index == 0 })
+}
+
+// IsLast is a template function that returns true if the index is the last one of the collection,
+// e.g. '{{ range $i, $e := .items }}{{ $e }}{{ if not (isLast $i $.items) }}, {{ end }}{{ end }}'
+func IsLast(index, collection interface{}) (bool, error) {
+	return positional("isLast", index, collection, func(index, length int) bool { return index == length-1 })
+}
+
+// positional implements IsFirst and IsLast, the element function gets the collection length
+// and returns true if the index is the one the function looks for
+func positional(function string, index, collection interface{}, is func(index, length int) bool) (bool, error) {
+	value := reflect.ValueOf(collection)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+	default:
+		return false, errors.Errorf("%s expects a collection, got type: '%T'", function, collection)
+	}
+	i, ok := asFloat(index)
+	if !ok {
+		return false, errors.Errorf("%s expects a number index, got type: '%T'", function, index)
+	}
+	length := value.Len()
+	return length > 0 && is(int(i), length), nil
+}
+
+func asFloats(function string, a, b interface{}) (float64, float64, error) {
+	x, ok := asFloat(a)
+	if !ok {
+		return 0, 0, errors.Errorf("%s expects numbers, got type: '%T'", function, a)
+	}
+	y, ok := asFloat(b)
+	if !ok {
+		return 0, 0, errors.Errorf("%s expects numbers, got type: '%T'", function, b)
+	}
+	return x, y, nil
+}
+
+func asFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
 // ReadFile is a template function that allows for an in-template file reading.
 // It takes a file path argument, the path can be absolute
 // or relative to the process working directory.
@@ -0,0 +1,130 @@
+package renderer
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localeNames are the month and weekday names of the languages supported by 'dateLocale'
+type localeNames struct {
+	months, shortMonths     [12]string
+	weekdays, shortWeekdays [7]string
+}
+
+var dateLocales = map[string]localeNames{
+	"en": {
+		months:        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortWeekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths:   [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortWeekdays: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"fr": {
+		months:        [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortWeekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"es": {
+		months:        [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortWeekdays: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"pl": {
+		months:        [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		shortMonths:   [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		weekdays:      [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		shortWeekdays: [7]string{"niedz.", "pon.", "wt.", "śr.", "czw.", "pt.", "sob."},
+	},
+}
+
+// layoutNames are the Go layout elements replaced by the localized names, the longer ones first
+var layoutNames = []string{"January", "Monday", "Jan", "Mon"}
+
+// LocaleFunctions provides the 'numberFormat' and 'dateLocale' template functions for the BCP 47 locale tag
+// (e.g. 'en-US' or 'de'), see RenderWithLocale:
+//   - numberFormat formats a number with the grouping and the given number of decimals,
+//     e.g. '{{ .price | numberFormat 2 }}' is '1,234.50' in 'en' and '1.234,50' in 'de'
+//   - dateLocale formats a time like the Sprig 'date' (with a Go layout), but the month and weekday names
+//     are localized, e.g. '{{ .released | dateLocale "2 January 2006" }}', the supported languages are
+//     en, de, fr, es and pl
+func LocaleFunctions(locale string) (template.FuncMap, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid locale: '%s'", locale)
+	}
+	printer := message.NewPrinter(tag)
+	base, _ := tag.Base()
+
+	return template.FuncMap{
+		"numberFormat": func(decimals int, value interface{}) (string, error) {
+			n, ok := asFloat(value)
+			if !ok {
+				return "", errors.Errorf("numberFormat expects a number, got type: '%T'", value)
+			}
+			return printer.Sprint(number.Decimal(n, number.Scale(decimals))), nil
+		},
+		"dateLocale": func(layout string, value interface{}) (string, error) {
+			names, ok := dateLocales[base.String()]
+			if !ok {
+				return "", errors.Errorf("dateLocale doesn't support the locale: '%s'", locale)
+			}
+			t, ok := value.(time.Time)
+			if !ok {
+				return "", errors.Errorf("dateLocale expects a time, got type: '%T'", value)
+			}
+			return formatLocalized(layout, t, names), nil
+		},
+	}, nil
+}
+
+// formatLocalized formats the time with the layout, replacing the month and weekday name elements
+func formatLocalized(layout string, t time.Time, names localeNames) string {
+	var b strings.Builder
+	for len(layout) > 0 {
+		next, element := len(layout), ""
+		for _, name := range layoutNames {
+			if i := strings.Index(layout, name); i >= 0 && i < next {
+				next, element = i, name
+			}
+		}
+		b.WriteString(t.Format(layout[:next]))
+		switch element {
+		case "January":
+			b.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			b.WriteString(names.shortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(names.weekdays[t.Weekday()])
+		case "Mon":
+			b.WriteString(names.shortWeekdays[t.Weekday()])
+		}
+		layout = layout[next+len(element):]
+	}
+	return b.String()
+}
+
+// RenderWithLocale renders the template like RenderTemplate with the 'numberFormat' and 'dateLocale'
+// template functions for the locale, see LocaleFunctions
+func RenderWithLocale(tmpl string, params parameters.Parameters, locale string) (string, error) {
+	functions, err := LocaleFunctions(locale)
+	if err != nil {
+		return "", err
+	}
+	configurators := append(defaultConfigurators(params), WithMoreFunctions(functions))
+	return New(configurators...).Render(tmpl)
+}
@@ -0,0 +1,388 @@
+package parameters
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Get returns the value for the dotted key (e.g. 'a.b.c') and whether it exists,
+// a numeric key segment indexes a slice (e.g. 'a.0.b')
+func (parameters Parameters) Get(key string) (interface{}, bool) {
+	if len(key) == 0 {
+		return nil, false
+	}
+	var current interface{} = parameters
+	for _, segment := range strings.Split(key, ".") {
+		if m, isMap := asMap(current); isMap {
+			var ok bool
+			current, ok = m[segment]
+			if !ok {
+				return nil, false
+			}
+			continue
+		}
+		switch value := current.(type) {
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, false
+			}
+			current = value[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Exists returns true if the dotted key exists, even if the value is nil
+func (parameters Parameters) Exists(key string) bool {
+	_, ok := parameters.Get(key)
+	return ok
+}
+
+// Keys returns the sorted top level keys
+func (parameters Parameters) Keys() []string {
+	return sortedKeys(parameters)
+}
+
+// Walk calls the function for every leaf (a value that is not a map) in the sorted key order,
+// with the path of keys leading to the leaf, the slices are leaves too
+func (parameters Parameters) Walk(fn func(path []string, value interface{})) {
+	walk(parameters, nil, fn)
+}
+
+func walk(current map[string]interface{}, path []string, fn func(path []string, value interface{})) {
+	for _, key := range sortedKeys(current) {
+		keyPath := append(path[:len(path):len(path)], key)
+		if nested, ok := asMap(current[key]); ok {
+			walk(nested, keyPath, fn)
+			continue
+		}
+		fn(keyPath, current[key])
+	}
+}
+
+// WalkStrings returns a copy of the parameters with the string leaves replaced by the function,
+// it is called in the sorted key order with the path leading to every string, also inside the slices
+// (the slice indexes are the path segments, e.g. 'hosts.0'), and returns the new string and true
+// to replace it or false to keep it, the other leaves are skipped
+func (parameters Parameters) WalkStrings(fn func(path []string, s string) (string, bool)) Parameters {
+	walked := parameters.Clone()
+	walkStrings(map[string]interface{}(walked), nil, fn)
+	return walked
+}
+
+// walkStrings returns the value with the string leaves replaced, the maps and the slices are changed in place
+func walkStrings(value interface{}, path []string, fn func(path []string, s string) (string, bool)) interface{} {
+	if m, ok := asMap(value); ok {
+		for _, key := range sortedKeys(m) {
+			m[key] = walkStrings(m[key], append(path[:len(path):len(path)], key), fn)
+		}
+		return value
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = walkStrings(v[i], append(path[:len(path):len(path)], strconv.Itoa(i)), fn)
+		}
+	case string:
+		if replaced, ok := fn(path, v); ok {
+			return replaced
+		}
+	}
+	return value
+}
+
+// Filter returns a copy of the parameters with only the leaves (see Walk) the function keeps,
+// the function is called with the path of keys and the value of every leaf,
+// the maps left empty are pruned
+func (parameters Parameters) Filter(keep func(path []string, value interface{}) bool) Parameters {
+	return Parameters(filterMap(parameters, nil, keep))
+}
+
+func filterMap(current map[string]interface{}, path []string, keep func(path []string, value interface{}) bool) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for _, key := range sortedKeys(current) {
+		keyPath := append(path[:len(path):len(path)], key)
+		if nested, ok := asMap(current[key]); ok {
+			if nestedFiltered := filterMap(nested, keyPath, keep); len(nestedFiltered) > 0 {
+				if _, ok := current[key].(Parameters); ok {
+					filtered[key] = Parameters(nestedFiltered)
+				} else {
+					filtered[key] = nestedFiltered
+				}
+			}
+			continue
+		}
+		if keep(keyPath, current[key]) {
+			filtered[key] = deepCopy(current[key])
+		}
+	}
+	return filtered
+}
+
+// Set assigns the value to the dotted key, the missing parent maps are created,
+// it returns an error if one of the parents exists and is not a map
+func (parameters *Parameters) Set(key string, value interface{}) error {
+	if parameters == nil {
+		return errors.New("unexpected nil parameters")
+	}
+	if len(key) == 0 {
+		return errors.New("unexpected empty key")
+	}
+	if *parameters == nil {
+		*parameters = Parameters{}
+	}
+	keys := strings.Split(key, ".")
+	lastIndex := len(keys) - 1
+
+	var current map[string]interface{} = *parameters
+	for _, k := range keys[:lastIndex] {
+		existing, ok := current[k]
+		if !ok || existing == nil {
+			current[k] = Parameters{}
+		}
+		next, ok := asMap(current[k])
+		if !ok {
+			return errors.Errorf(
+				"key conflict: key '%s' already exists and is not a map, it has type: '%s'",
+				k, reflect.TypeOf(existing))
+		}
+		current = next
+	}
+	current[keys[lastIndex]] = value
+	return nil
+}
+
+// SetDefault assigns the value to the dotted key like Set, but only if the key doesn't exist yet,
+// a key with a nil value exists, it returns true if the value was set
+func (parameters *Parameters) SetDefault(key string, value interface{}) (bool, error) {
+	if parameters != nil && parameters.Exists(key) {
+		return false, nil
+	}
+	err := parameters.Set(key, value)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the dotted key and returns true if it existed
+func (parameters *Parameters) Delete(key string) bool {
+	if parameters == nil || len(key) == 0 {
+		return false
+	}
+	keys := strings.Split(key, ".")
+	lastIndex := len(keys) - 1
+
+	var current map[string]interface{} = *parameters
+	for _, k := range keys[:lastIndex] {
+		next, ok := asMap(current[k])
+		if !ok {
+			return false
+		}
+		current = next
+	}
+	_, ok := current[keys[lastIndex]]
+	delete(current, keys[lastIndex])
+	return ok
+}
+
+// Rename moves the value of the dotted key to the other dotted key, it returns an error
+// if the key doesn't exist, if the other key already exists or if one of its parents is not a map,
+// the parameters are not modified on an error
+func (parameters *Parameters) Rename(from, to string) error {
+	if parameters == nil {
+		return errors.New("unexpected nil parameters")
+	}
+	value, ok := parameters.Get(from)
+	if !ok {
+		return errors.Errorf("can't rename key '%s', it doesn't exist", from)
+	}
+	if from == to {
+		return nil
+	}
+	if parameters.Exists(to) {
+		return errors.Errorf("can't rename key '%s', key '%s' already exists", from, to)
+	}
+
+	parameters.Delete(from)
+	err := parameters.Set(to, value)
+	if err != nil {
+		_ = parameters.Set(from, value)
+		return errors.Wrapf(err, "can't rename key '%s' to '%s'", from, to)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the parameters, the nested maps and slices are copied too
+func (parameters Parameters) Clone() Parameters {
+	if parameters == nil {
+		return nil
+	}
+	return Parameters(deepCopyMap(parameters))
+}
+
+// EqualIgnoring returns true if the parameters are deeply equal, except for the ignored dotted keys,
+// an ignored key matches at any nesting level, e.g. 'meta.updated' ignores 'meta.updated' and 'services.0.meta.updated',
+// the nested Parameters and map[string]interface{} are equal if they have the same keys and values
+func (parameters Parameters) EqualIgnoring(other Parameters, ignorePaths ...string) bool {
+	ignored := make([][]string, len(ignorePaths))
+	for i, path := range ignorePaths {
+		ignored[i] = strings.Split(path, ".")
+	}
+	return equalIgnoring(parameters, other, nil, ignored)
+}
+
+func equalIgnoring(a, b interface{}, path []string, ignored [][]string) bool {
+	aMap, aIsMap := asMap(a)
+	bMap, bIsMap := asMap(b)
+	if aIsMap || bIsMap {
+		if !aIsMap || !bIsMap {
+			return false
+		}
+		for _, keys := range [][]string{sortedKeys(aMap), sortedKeys(bMap)} {
+			for _, key := range keys {
+				keyPath := append(path[:len(path):len(path)], key)
+				if isIgnored(keyPath, ignored) {
+					continue
+				}
+				aValue, aExists := aMap[key]
+				bValue, bExists := bMap[key]
+				if aExists != bExists || !equalIgnoring(aValue, bValue, keyPath, ignored) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	aSlice, aIsSlice := a.([]interface{})
+	bSlice, bIsSlice := b.([]interface{})
+	if aIsSlice && bIsSlice {
+		if len(aSlice) != len(bSlice) {
+			return false
+		}
+		for i := range aSlice {
+			elementPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if !isIgnored(elementPath, ignored) && !equalIgnoring(aSlice[i], bSlice[i], elementPath, ignored) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isIgnored returns true if any of the ignored paths is a suffix of the path
+func isIgnored(path []string, ignored [][]string) bool {
+	for _, suffix := range ignored {
+		if len(suffix) > len(path) {
+			continue
+		}
+		if reflect.DeepEqual(path[len(path)-len(suffix):], suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnlyParameters is a read-only snapshot of parameters, see Parameters.Freeze,
+// it is safe for concurrent use and only returns copies of the nested values
+type ReadOnlyParameters struct {
+	parameters Parameters
+}
+
+// Freeze returns a read-only snapshot of the parameters,
+// a mutable copy can be obtained with ReadOnlyParameters.Clone
+func (parameters Parameters) Freeze() ReadOnlyParameters {
+	return ReadOnlyParameters{parameters: parameters.Clone()}
+}
+
+// Get returns a copy of the value for the dotted key and whether it exists, see Parameters.Get
+func (r ReadOnlyParameters) Get(key string) (interface{}, bool) {
+	value, ok := r.parameters.Get(key)
+	return deepCopy(value), ok
+}
+
+// Exists returns true if the dotted key exists, see Parameters.Exists
+func (r ReadOnlyParameters) Exists(key string) bool {
+	return r.parameters.Exists(key)
+}
+
+// Keys returns the sorted top level keys, see Parameters.Keys
+func (r ReadOnlyParameters) Keys() []string {
+	return r.parameters.Keys()
+}
+
+// Walk calls the function with a copy of every leaf, see Parameters.Walk
+func (r ReadOnlyParameters) Walk(fn func(path []string, value interface{})) {
+	r.parameters.Walk(func(path []string, value interface{}) {
+		fn(path, deepCopy(value))
+	})
+}
+
+// Clone returns a mutable deep copy of the parameters
+func (r ReadOnlyParameters) Clone() Parameters {
+	return r.parameters.Clone()
+}
+
+// Flatten returns the leaves as a flat map with the dotted keys, the slice elements
+// use the bracket indices (e.g. 'a.b[0].c'), the empty maps and slices are leaves too
+func (parameters Parameters) Flatten() map[string]interface{} {
+	flat := map[string]interface{}{}
+	flattenMap(flat, "", parameters)
+	return flat
+}
+
+// Unflatten creates a configuration from the flat map with the dotted keys, the inverse of Flatten,
+// the bracket indices create the slices (e.g. 'list[1]', the missing elements are nil),
+// it returns an error if a key is both a value and a parent of another key, e.g. 'a' and 'a.b'
+func Unflatten(flat map[string]interface{}) (Parameters, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	config := Parameters{}
+	for _, key := range keys {
+		err := setIndexed(&config, key, deepCopy(flat[key]), false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't unflatten key '%s'", key)
+		}
+	}
+	return config, nil
+}
+
+func flattenMap(flat map[string]interface{}, prefix string, current map[string]interface{}) {
+	for key, value := range current {
+		flattenValue(flat, joinKey(prefix, key), value)
+	}
+}
+
+func flattenValue(flat map[string]interface{}, key string, value interface{}) {
+	if nested, ok := asMap(value); ok && len(nested) > 0 {
+		flattenMap(flat, key, nested)
+		return
+	}
+	if slice, ok := value.([]interface{}); ok && len(slice) > 0 {
+		for i, element := range slice {
+			flattenValue(flat, key+"["+strconv.Itoa(i)+"]", element)
+		}
+		return
+	}
+	flat[key] = value
+}
+
+func joinKey(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "." + key
+}
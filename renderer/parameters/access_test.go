@@ -0,0 +1,383 @@
+package parameters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameters_Get(t *testing.T) {
+	params := Parameters{
+		"a": Parameters{
+			"b":    map[string]interface{}{"c": "value"},
+			"nil":  nil,
+			"list": []interface{}{Parameters{"name": "first"}},
+		},
+	}
+
+	tests := []struct {
+		key    string
+		want   interface{}
+		wantOk bool
+	}{
+		{key: "a.b.c", want: "value", wantOk: true},
+		{key: "a.nil", want: nil, wantOk: true},
+		{key: "a.list.0.name", want: "first", wantOk: true},
+		{key: "a.list.1.name", want: nil, wantOk: false},
+		{key: "a.b.c.d", want: nil, wantOk: false},
+		{key: "missing", want: nil, wantOk: false},
+		{key: "", want: nil, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := params.Get(tt.key)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOk, params.Exists(tt.key))
+		})
+	}
+}
+
+func TestParameters_SetDelete(t *testing.T) {
+	params := Parameters{"a": map[string]interface{}{"b": "value"}, "scalar": "x"}
+
+	assert.NoError(t, params.Set("a.c.d", 1))
+	assert.NoError(t, params.Set("e", 2))
+	assert.EqualError(t, params.Set("scalar.nested", 3),
+		"key conflict: key 'scalar' already exists and is not a map, it has type: 'string'")
+	assert.Equal(t, Parameters{
+		"a":      map[string]interface{}{"b": "value", "c": Parameters{"d": 1}},
+		"e":      2,
+		"scalar": "x",
+	}, params)
+
+	assert.True(t, params.Delete("a.c.d"))
+	assert.False(t, params.Delete("a.c.d"))
+	assert.False(t, params.Delete("scalar.nested"))
+	assert.Equal(t, Parameters{
+		"a":      map[string]interface{}{"b": "value", "c": Parameters{}},
+		"e":      2,
+		"scalar": "x",
+	}, params)
+}
+
+func TestParameters_Walk(t *testing.T) {
+	params := Parameters{
+		"b": Parameters{"d": 2, "c": 1},
+		"a": []interface{}{"x"},
+	}
+
+	var paths []string
+	var values []interface{}
+	params.Walk(func(path []string, value interface{}) {
+		paths = append(paths, strings.Join(path, "."))
+		values = append(values, value)
+	})
+	assert.Equal(t, []string{"a", "b.c", "b.d"}, paths)
+	assert.Equal(t, []interface{}{[]interface{}{"x"}, 1, 2}, values)
+	assert.Equal(t, []string{"a", "b"}, params.Keys())
+}
+
+func TestParameters_WalkStrings(t *testing.T) {
+	params := Parameters{
+		"db": Parameters{"host": "${HOST}", "port": 5432, "debug": true},
+		"hosts": []interface{}{
+			"${HOST}",
+			map[string]interface{}{"name": "${HOST}", "weight": 1.5},
+			[]interface{}{"plain", nil},
+		},
+		"name": "app",
+	}
+
+	var paths []string
+	got := params.WalkStrings(func(path []string, s string) (string, bool) {
+		paths = append(paths, strings.Join(path, "."))
+		if s != "${HOST}" {
+			return "", false
+		}
+		return "localhost", true
+	})
+
+	assert.Equal(t, []string{"db.host", "hosts.0", "hosts.1.name", "hosts.2.0", "name"}, paths)
+	assert.Equal(t, Parameters{
+		"db": Parameters{"host": "localhost", "port": 5432, "debug": true},
+		"hosts": []interface{}{
+			"localhost",
+			map[string]interface{}{"name": "localhost", "weight": 1.5},
+			[]interface{}{"plain", nil},
+		},
+		"name": "app",
+	}, got)
+	host, _ := params.Get("db.host")
+	assert.Equal(t, "${HOST}", host, "the original is unchanged")
+	assert.Equal(t, "${HOST}", params["hosts"].([]interface{})[0], "the original slice is unchanged")
+}
+
+func TestParameters_Freeze(t *testing.T) {
+	source := Parameters{
+		"db":   Parameters{"host": "localhost"},
+		"tags": []interface{}{"a"},
+	}
+	frozen := source.Freeze()
+
+	t.Run("reflects the source", func(t *testing.T) {
+		host, ok := frozen.Get("db.host")
+		assert.True(t, ok)
+		assert.Equal(t, "localhost", host)
+		assert.True(t, frozen.Exists("tags"))
+		assert.Equal(t, []string{"db", "tags"}, frozen.Keys())
+		assert.Equal(t, source, frozen.Clone())
+
+		var count int
+		frozen.Walk(func(path []string, value interface{}) { count++ })
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("no mutation path", func(t *testing.T) {
+		db, _ := frozen.Get("db")
+		db.(Parameters)["host"] = "changed"
+		tags, _ := frozen.Get("tags")
+		tags.([]interface{})[0] = "changed"
+		clone := frozen.Clone()
+		assert.NoError(t, clone.Set("db.host", "changed"))
+		source["db"].(Parameters)["host"] = "changed"
+
+		host, _ := frozen.Get("db.host")
+		assert.Equal(t, "localhost", host)
+		tag, _ := frozen.Get("tags.0")
+		assert.Equal(t, "a", tag)
+
+		frozenType := reflect.TypeOf(frozen)
+		for i := 0; i < frozenType.NumMethod(); i++ {
+			assert.Contains(t, []string{"Clone", "Exists", "Get", "Keys", "Walk"}, frozenType.Method(i).Name)
+		}
+	})
+}
+
+func TestParameters_EqualIgnoring(t *testing.T) {
+	base := Parameters{
+		"name": "render",
+		"meta": Parameters{"updated": "2020-01-01", "id": "a1"},
+		"services": []interface{}{
+			Parameters{"name": "api", "meta": Parameters{"updated": "2020-01-01"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		other    Parameters
+		ignore   []string
+		expected bool
+	}{
+		{
+			name: "only ignored fields differ",
+			other: Parameters{
+				"name": "render",
+				"meta": map[string]interface{}{"updated": "2021-02-02", "id": "b2"},
+				"services": []interface{}{
+					Parameters{"name": "api", "meta": Parameters{"updated": "2021-02-02"}},
+				},
+			},
+			ignore:   []string{"meta.updated", "id"},
+			expected: true,
+		},
+		{
+			name: "non-ignored field differs",
+			other: Parameters{
+				"name": "other",
+				"meta": Parameters{"updated": "2021-02-02", "id": "a1"},
+				"services": []interface{}{
+					Parameters{"name": "api", "meta": Parameters{"updated": "2021-02-02"}},
+				},
+			},
+			ignore:   []string{"meta.updated"},
+			expected: false,
+		},
+		{
+			name: "ignored field missing",
+			other: Parameters{
+				"name": "render",
+				"meta": Parameters{"id": "a1"},
+				"services": []interface{}{
+					Parameters{"name": "api", "meta": Parameters{}},
+				},
+			},
+			ignore:   []string{"updated"},
+			expected: true,
+		},
+		{
+			name:     "nothing ignored",
+			other:    base.Clone(),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, base.EqualIgnoring(tt.other, tt.ignore...))
+			assert.Equal(t, tt.expected, tt.other.EqualIgnoring(base, tt.ignore...))
+		})
+	}
+}
+
+func TestParameters_Filter(t *testing.T) {
+	params := Parameters{
+		"name": "render",
+		"db": Parameters{
+			"host": "localhost",
+			"port": 5432,
+			"auth": Parameters{"user": "admin", "password": "s3cr3t"},
+		},
+		"tags":  []interface{}{"a", "b"},
+		"empty": Parameters{},
+	}
+
+	t.Run("under prefix", func(t *testing.T) {
+		got := params.Filter(func(path []string, value interface{}) bool {
+			return path[0] == "db" && len(path) == 2
+		})
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost", "port": 5432}}, got)
+	})
+
+	t.Run("by value type", func(t *testing.T) {
+		got := params.Filter(func(path []string, value interface{}) bool {
+			_, ok := value.(string)
+			return ok
+		})
+		assert.Equal(t, Parameters{
+			"name": "render",
+			"db": Parameters{
+				"host": "localhost",
+				"auth": Parameters{"user": "admin", "password": "s3cr3t"},
+			},
+		}, got)
+	})
+
+	t.Run("empty parents pruned", func(t *testing.T) {
+		got := params.Filter(func(path []string, value interface{}) bool {
+			return strings.Join(path, ".") != "db.auth.user" && strings.Join(path, ".") != "db.auth.password"
+		})
+		assert.Equal(t, Parameters{
+			"name": "render",
+			"db":   Parameters{"host": "localhost", "port": 5432},
+			"tags": []interface{}{"a", "b"},
+		}, got)
+		assert.Equal(t, "admin", params["db"].(Parameters)["auth"].(Parameters)["user"], "the original should not be modified")
+	})
+}
+
+func TestParameters_SetDefault(t *testing.T) {
+	params := Parameters{
+		"db":   Parameters{"host": "localhost", "user": nil},
+		"name": "render",
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		set, err := params.SetDefault("db.port", 5432)
+		assert.NoError(t, err)
+		assert.True(t, set)
+		assert.Equal(t, 5432, params["db"].(Parameters)["port"])
+	})
+
+	t.Run("present key", func(t *testing.T) {
+		set, err := params.SetDefault("db.host", "remote")
+		assert.NoError(t, err)
+		assert.False(t, set)
+		assert.Equal(t, "localhost", params["db"].(Parameters)["host"])
+	})
+
+	t.Run("present nil", func(t *testing.T) {
+		set, err := params.SetDefault("db.user", "admin")
+		assert.NoError(t, err)
+		assert.False(t, set)
+		assert.Nil(t, params["db"].(Parameters)["user"])
+	})
+
+	t.Run("key conflict", func(t *testing.T) {
+		set, err := params.SetDefault("name.first", "render")
+		assert.EqualError(t, err, "key conflict: key 'name' already exists and is not a map, it has type: 'string'")
+		assert.False(t, set)
+	})
+}
+
+func TestParameters_Rename(t *testing.T) {
+	t.Run("renamed", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"hostname": "localhost", "port": 5432}}
+		err := params.Rename("db.hostname", "db.host")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost", "port": 5432}}, params)
+	})
+
+	t.Run("moved to a new parent", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"host": "localhost"}}
+		err := params.Rename("db.host", "database.primary.host")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":       Parameters{},
+			"database": Parameters{"primary": Parameters{"host": "localhost"}},
+		}, params)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"host": "localhost"}}
+		err := params.Rename("db.hostname", "db.host")
+		assert.EqualError(t, err, "can't rename key 'db.hostname', it doesn't exist")
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost"}}, params)
+	})
+
+	t.Run("destination exists", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"hostname": "localhost", "host": "remote"}}
+		err := params.Rename("db.hostname", "db.host")
+		assert.EqualError(t, err, "can't rename key 'db.hostname', key 'db.host' already exists")
+		assert.Equal(t, Parameters{"db": Parameters{"hostname": "localhost", "host": "remote"}}, params)
+	})
+
+	t.Run("destination parent conflict", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"hostname": "localhost"}, "name": "render"}
+		err := params.Rename("db.hostname", "name.host")
+		assert.EqualError(t, err, "can't rename key 'db.hostname' to 'name.host': "+
+			"key conflict: key 'name' already exists and is not a map, it has type: 'string'")
+		assert.Equal(t, Parameters{"db": Parameters{"hostname": "localhost"}, "name": "render"}, params)
+	})
+}
+
+func TestParameters_ToFlatMapString(t *testing.T) {
+	params := Parameters{
+		"name":  "render",
+		"db":    Parameters{"port": 5432, "ratio": 0.5, "ssl": true, "user": nil},
+		"hosts": []interface{}{"a", int64(2)},
+		"retry": 30 * time.Second,
+	}
+
+	t.Run("mixed types", func(t *testing.T) {
+		got, err := params.ToFlatMapString()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"name":     "render",
+			"db.port":  "5432",
+			"db.ratio": "0.5",
+			"db.ssl":   "true",
+			"db.user":  "",
+			"hosts[0]": "a",
+			"hosts[1]": "2",
+			"retry":    "30s",
+		}, got)
+	})
+
+	complexParams := Parameters{"labels": Parameters{}, "tags": []interface{}{}, "point": struct{ X int }{1}}
+
+	t.Run("complex value", func(t *testing.T) {
+		got, err := complexParams.ToFlatMapString()
+		assert.EqualError(t, err, "can't stringify key 'labels': unexpected complex value of type: 'parameters.Parameters'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("complex values as JSON", func(t *testing.T) {
+		got, err := complexParams.ToFlatMapString(WithJSONComplexValues())
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"labels": "{}", "tags": "[]", "point": `{"X":1}`}, got)
+	})
+}
@@ -0,0 +1,174 @@
+package parameters
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ToCanonicalYAML turns the parameters into a stable YAML document, meant for the golden file comparisons:
+//   - the maps and the slices are always in the block style, only the empty ones are '{}' and '[]'
+//   - the map keys are sorted and the indentation is 2 spaces
+//   - the lines are never wrapped
+//   - a string is plain, unless it would be read back as something else than the same string
+//     (e.g. 'yes', '123', 'null', an empty string or ' padded'), then it is double quoted, the same goes for
+//     the multi-line strings, so the newlines are escaped and every value stays on a single line
+func ToCanonicalYAML(parameters Parameters) ([]byte, error) {
+	node, err := canonicalNode(parameters.Map())
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to canonical YAML")
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	err = encoder.Encode(node)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to canonical YAML")
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to canonical YAML")
+	}
+	return b.Bytes(), nil
+}
+
+func canonicalNode(value interface{}) (*yaml.Node, error) {
+	if m, ok := asMap(value); ok {
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range sortedKeys(m) {
+			child, err := canonicalNode(m[k])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, canonicalString(k), child)
+		}
+		if len(node.Content) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		return node, nil
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, element := range value {
+			child, err := canonicalNode(element)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		if len(node.Content) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		return node, nil
+	case string:
+		return canonicalString(value), nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	default:
+		node := &yaml.Node{}
+		err := node.Encode(value)
+		if err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
+
+// yaml11Booleans are the YAML 1.1 booleans still read as booleans by many parsers
+var yaml11Booleans = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+}
+
+func canonicalString(s string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+	if needsQuoting(s) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// needsQuoting returns true if the plain string wouldn't be read back as the same string
+func needsQuoting(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\r\t") {
+		return true
+	}
+	if yaml11Booleans[strings.ToLower(s)] {
+		return true
+	}
+	var decoded interface{}
+	err := yaml.Unmarshal([]byte(s), &decoded)
+	if err != nil {
+		return true
+	}
+	decodedString, ok := decoded.(string)
+	return !ok || decodedString != s
+}
+
+// DiffText compares the canonical YAML documents (see ToCanonicalYAML) of the parameters line by line and
+// returns the lines of both in a unified diff like text, e.g. for a review of the configuration changes,
+// the removed lines start with '-', the added ones with '+' and the unchanged (context) ones with a space.
+// A serialization error is logged and an empty text is returned
+func DiffText(a, b Parameters) string {
+	before, err := ToCanonicalYAML(a)
+	if err != nil {
+		logrus.Errorf("Can't diff the parameters: %v", err)
+		return ""
+	}
+	after, err := ToCanonicalYAML(b)
+	if err != nil {
+		logrus.Errorf("Can't diff the parameters: %v", err)
+		return ""
+	}
+
+	var text strings.Builder
+	for _, line := range diffLines(strings.Split(strings.TrimSuffix(string(before), "\n"), "\n"),
+		strings.Split(strings.TrimSuffix(string(after), "\n"), "\n")) {
+		text.WriteString(line)
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// diffLines returns the lines of both with the '-', '+' or ' ' prefixes, by their longest common subsequence
+func diffLines(before, after []string) []string {
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, " "+before[i])
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "-"+before[i])
+			i++
+		default:
+			lines = append(lines, "+"+after[j])
+			j++
+		}
+	}
+	return lines
+}
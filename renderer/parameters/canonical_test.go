@@ -0,0 +1,95 @@
+package parameters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCanonicalYAML(t *testing.T) {
+	params := Parameters{
+		"name":    "service",
+		"enabled": "yes",
+		"version": "123",
+		"count":   3,
+		"empty":   "",
+		"long":    "a very long line that would be wrapped by an encoder limiting the width of the lines to 80 characters",
+		"text":    "first\nsecond",
+		"colon":   "key: value",
+		"nested": Parameters{
+			"z":     true,
+			"a":     []interface{}{"plain", "null", 1.5},
+			"none":  nil,
+			"list":  []interface{}{},
+			"inner": Parameters{},
+		},
+	}
+	expected := `colon: "key: value"
+count: 3
+empty: ""
+enabled: "yes"
+long: a very long line that would be wrapped by an encoder limiting the width of the lines to 80 characters
+name: service
+nested:
+  a:
+    - plain
+    - "null"
+    - 1.5
+  inner: {}
+  list: []
+  none: null
+  z: true
+text: "first\nsecond"
+version: "123"
+`
+
+	got, err := ToCanonicalYAML(params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(got))
+
+	for i := 0; i < 10; i++ {
+		again, err := ToCanonicalYAML(params.Clone())
+		assert.NoError(t, err)
+		assert.Equal(t, got, again, "output should be byte stable")
+	}
+
+	back, err := FromYAML(bytes.NewReader(got))
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", back["enabled"])
+	assert.Equal(t, "123", back["version"])
+	assert.Equal(t, "first\nsecond", back["text"])
+}
+
+func TestDiffText(t *testing.T) {
+	before := Parameters{
+		"name": "app",
+		"db":   Parameters{"host": "localhost", "port": 5432},
+		"old":  true,
+	}
+	after := Parameters{
+		"name": "app",
+		"db":   Parameters{"host": "remote", "port": 5432},
+		"new":  []interface{}{"a"},
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		assert.Equal(t, ` db:
+-  host: localhost
++  host: remote
+   port: 5432
+ name: app
+-old: true
++new:
++  - a
+`, DiffText(before, after))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		assert.Equal(t, " db:\n   host: localhost\n   port: 5432\n name: app\n old: true\n", DiffText(before, before.Clone()))
+	})
+
+	t.Run("from empty", func(t *testing.T) {
+		assert.Equal(t, "-{}\n+name: app\n", DiffText(Parameters{}, Parameters{"name": "app"}))
+	})
+}
@@ -0,0 +1,349 @@
+package parameters
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Compute evaluates the expressions and stores the results under the given dotted keys in a copy of the parameters.
+// The expressions support the string (double quoted) and number literals, dotted key references,
+// the parentheses and the '+', '-', '*', '/' operators, the '+' concatenates strings and adds numbers,
+// e.g. 'first + " " + last', the key references are letters, digits, underscores and dots,
+// so 'a-b' is a subtraction. The expressions referencing the other computed keys are evaluated
+// in the dependency order, a reference cycle is an error.
+func Compute(parameters Parameters, exprs map[string]string) (Parameters, error) {
+	parsed := make(map[string]expression, len(exprs))
+	for _, key := range sortedStringKeys(exprs) {
+		e, err := parseExpression(exprs[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't parse the expression for '%s'", key)
+		}
+		parsed[key] = e
+	}
+
+	order, err := computeOrder(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := parameters.Clone()
+	if result == nil {
+		result = Parameters{}
+	}
+	for _, key := range order {
+		value, err := parsed[key].eval(result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't evaluate the expression for '%s'", key)
+		}
+		err = result.Set(key, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't store the result for '%s'", key)
+		}
+	}
+	return result, nil
+}
+
+// computeOrder sorts the computed keys topologically, so that the dependencies go first
+func computeOrder(parsed map[string]expression) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(parsed))
+	var order []string
+
+	var visit func(key string, chain []string) error
+	visit = func(key string, chain []string) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("reference cycle: %s", strings.Join(append(chain, key), " -> "))
+		}
+		state[key] = visiting
+		for _, reference := range parsed[key].references() {
+			for _, dependency := range sortedExpressionKeys(parsed) {
+				if reference == dependency || strings.HasPrefix(reference, dependency+".") {
+					err := visit(dependency, append(chain, key))
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		state[key] = visited
+		order = append(order, key)
+		return nil
+	}
+
+	for _, key := range sortedExpressionKeys(parsed) {
+		err := visit(key, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// expression is a node of the parsed expression tree
+type expression interface {
+	eval(parameters Parameters) (interface{}, error)
+	references() []string
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) eval(Parameters) (interface{}, error) { return l.value, nil }
+func (l literal) references() []string                 { return nil }
+
+type reference struct {
+	key string
+}
+
+func (r reference) eval(parameters Parameters) (interface{}, error) {
+	value, ok := parameters.Get(r.key)
+	if !ok {
+		return nil, errors.Errorf("missing key: '%s'", r.key)
+	}
+	return value, nil
+}
+
+func (r reference) references() []string { return []string{r.key} }
+
+type binary struct {
+	operator    rune
+	left, right expression
+}
+
+func (b binary) references() []string {
+	return append(b.left.references(), b.right.references()...)
+}
+
+func (b binary) eval(parameters Parameters) (interface{}, error) {
+	left, err := b.left.eval(parameters)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.right.eval(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString && b.operator == '+' {
+		return leftString + rightString, nil
+	}
+
+	leftNumber, leftIsInt, leftOk := asNumber(left)
+	rightNumber, rightIsInt, rightOk := asNumber(right)
+	if !leftOk || !rightOk {
+		return nil, errors.Errorf("unsupported operands for '%c': '%T' and '%T'", b.operator, left, right)
+	}
+
+	var result float64
+	switch b.operator {
+	case '+':
+		result = leftNumber + rightNumber
+	case '-':
+		result = leftNumber - rightNumber
+	case '*':
+		result = leftNumber * rightNumber
+	case '/':
+		if rightNumber == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return leftNumber / rightNumber, nil
+	}
+	if leftIsInt && rightIsInt && result == math.Trunc(result) {
+		return int64(result), nil
+	}
+	return result, nil
+}
+
+// asNumber returns the number as float64 and whether it is an integer type
+func asNumber(value interface{}) (float64, bool, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// expressionParser is a simple recursive descent parser:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = factor { ("*" | "/") factor }
+//	factor     = number | string | key | "(" expression ")"
+type expressionParser struct {
+	input    []rune
+	position int
+}
+
+func parseExpression(input string) (expression, error) {
+	p := &expressionParser{input: []rune(input)}
+	e, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.position < len(p.input) {
+		return nil, errors.Errorf("unexpected '%c' at %d", p.input[p.position], p.position)
+	}
+	return e, nil
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.position < len(p.input) && unicode.IsSpace(p.input[p.position]) {
+		p.position++
+	}
+}
+
+func (p *expressionParser) peek() (rune, bool) {
+	p.skipSpaces()
+	if p.position >= len(p.input) {
+		return 0, false
+	}
+	return p.input[p.position], true
+}
+
+func (p *expressionParser) expression() (expression, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.peek()
+		if !ok || (operator != '+' && operator != '-') {
+			return left, nil
+		}
+		p.position++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) term() (expression, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.peek()
+		if !ok || (operator != '*' && operator != '/') {
+			return left, nil
+		}
+		p.position++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) factor() (expression, error) {
+	next, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of the expression")
+	}
+	start := p.position
+	switch {
+	case next == '(':
+		p.position++
+		e, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing != ')' {
+			return nil, errors.Errorf("expected ')' at %d", p.position)
+		}
+		p.position++
+		return e, nil
+	case next == '"':
+		p.position++
+		for p.position < len(p.input) && p.input[p.position] != '"' {
+			if p.input[p.position] == '\\' {
+				p.position++
+			}
+			p.position++
+		}
+		if p.position >= len(p.input) {
+			return nil, errors.Errorf("unterminated string at %d", start)
+		}
+		p.position++
+		value, err := strconv.Unquote(string(p.input[start:p.position]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid string at %d", start)
+		}
+		return literal{value: value}, nil
+	case unicode.IsDigit(next):
+		isFloat := false
+		for p.position < len(p.input) && (unicode.IsDigit(p.input[p.position]) || p.input[p.position] == '.') {
+			if p.input[p.position] == '.' {
+				isFloat = true
+			}
+			p.position++
+		}
+		text := string(p.input[start:p.position])
+		if isFloat {
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid number at %d", start)
+			}
+			return literal{value: value}, nil
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid number at %d", start)
+		}
+		return literal{value: value}, nil
+	case unicode.IsLetter(next) || next == '_':
+		for p.position < len(p.input) && isKeyRune(p.input[p.position]) {
+			p.position++
+		}
+		return reference{key: string(p.input[start:p.position])}, nil
+	default:
+		return nil, errors.Errorf("unexpected '%c' at %d", next, start)
+	}
+}
+
+func isKeyRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedExpressionKeys(m map[string]expression) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
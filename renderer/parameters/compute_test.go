@@ -0,0 +1,63 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	t.Run("concatenation", func(t *testing.T) {
+		params := Parameters{"first": "Jane", "last": "Doe"}
+		got, err := Compute(params, map[string]string{
+			"full_name": `first + " " + last`,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"first":     "Jane",
+			"last":      "Doe",
+			"full_name": "Jane Doe",
+		}, got)
+		assert.Equal(t, Parameters{"first": "Jane", "last": "Doe"}, params, "should not be mutated")
+	})
+
+	t.Run("numeric in dependency order", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"replicas": 3, "memory": 1.5}}
+		got, err := Compute(params, map[string]string{
+			"total.memory":   "db.memory * total.replicas",
+			"total.replicas": "(db.replicas + 1) * 2",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db": Parameters{"replicas": 3, "memory": 1.5},
+			"total": Parameters{
+				"replicas": int64(8),
+				"memory":   12.0,
+			},
+		}, got)
+	})
+
+	t.Run("subtraction without spaces", func(t *testing.T) {
+		got, err := Compute(Parameters{"a": 5, "b": 3}, map[string]string{"c": "a-b", "d": "a - b-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got["c"])
+		assert.Equal(t, int64(1), got["d"])
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := Compute(Parameters{}, map[string]string{
+			"a": "b + 1",
+			"b": "c + 1",
+			"c": "a + 1",
+		})
+		assert.EqualError(t, err, "reference cycle: a -> b -> c -> a")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Compute(Parameters{}, map[string]string{"a": `"x" +`})
+		assert.EqualError(t, err, "can't parse the expression for 'a': unexpected end of the expression")
+
+		_, err = Compute(Parameters{"s": "x"}, map[string]string{"a": `s * 2`})
+		assert.EqualError(t, err, "can't evaluate the expression for 'a': unsupported operands for '*': 'string' and 'int64'")
+	})
+}
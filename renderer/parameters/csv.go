@@ -0,0 +1,112 @@
+package parameters
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CSVOption mutates the FromCSV configuration
+type CSVOption func(*csvConfig)
+
+type csvConfig struct {
+	inferTypes bool
+}
+
+// WithInferredTypes makes FromCSV convert the columns with only integers to int64, the columns with only
+// numbers to float64 and the columns with only 'true' or 'false' (case insensitive) to bool,
+// a column with any other value (also an empty one) stays as strings
+func WithInferredTypes() CSVOption {
+	return func(c *csvConfig) {
+		c.inferTypes = true
+	}
+}
+
+// FromCSV creates a configuration from a CSV document with a header row and zero or more options
+// e.g. WithInferredTypes, the rows are stored at the dotted key as a slice of parameters
+// keyed by the header, by default all the values are strings
+func FromCSV(r io.Reader, key string, options ...CSVOption) (Parameters, error) {
+	var c csvConfig
+	for _, option := range options {
+		option(&c)
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse CSV")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("can't parse CSV: missing header row")
+	}
+	header := records[0]
+	seen := map[string]bool{}
+	for _, name := range header {
+		if seen[name] {
+			return nil, errors.Errorf("can't parse CSV: duplicate column '%s'", name)
+		}
+		seen[name] = true
+	}
+
+	columns := make([]func(string) interface{}, len(header))
+	for i := range header {
+		columns[i] = func(s string) interface{} { return s }
+		if c.inferTypes {
+			columns[i] = inferColumn(records[1:], i)
+		}
+	}
+
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := Parameters{}
+		for i, name := range header {
+			row[name] = columns[i](record[i])
+		}
+		rows = append(rows, row)
+	}
+
+	config := Parameters{}
+	err = config.Set(key, rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse CSV")
+	}
+	return config, nil
+}
+
+// inferColumn returns the conversion of the values of the column, the most specific one valid for all the rows
+func inferColumn(rows [][]string, column int) func(string) interface{} {
+	conversions := []func(string) (interface{}, bool){
+		parseInt,
+		func(s string) (interface{}, bool) {
+			if !strings.ContainsAny(s, "0123456789") {
+				return nil, false
+			}
+			return parseFloat(s)
+		},
+		func(s string) (interface{}, bool) {
+			if !strings.EqualFold(s, "true") && !strings.EqualFold(s, "false") {
+				return nil, false
+			}
+			return parseBool(strings.ToLower(s))
+		},
+	}
+
+	for _, conversion := range conversions {
+		valid := len(rows) > 0
+		for _, row := range rows {
+			if _, ok := conversion(row[column]); !ok {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			conversion := conversion
+			return func(s string) interface{} {
+				value, _ := conversion(s)
+				return value
+			}
+		}
+	}
+	return func(s string) interface{} { return s }
+}
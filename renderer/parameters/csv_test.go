@@ -0,0 +1,52 @@
+package parameters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCSV(t *testing.T) {
+	t.Run("two columns", func(t *testing.T) {
+		document := "name,port\nweb,80\ndb,5432\n"
+		got, err := FromCSV(strings.NewReader(document), "services.list")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"services": Parameters{
+				"list": []interface{}{
+					Parameters{"name": "web", "port": "80"},
+					Parameters{"name": "db", "port": "5432"},
+				},
+			},
+		}, got)
+	})
+
+	t.Run("inferred types", func(t *testing.T) {
+		document := "name,port,weight,enabled,version\nweb,80,0.5,true,1\ndb,5432,2,FALSE,1.2.3\n"
+		got, err := FromCSV(strings.NewReader(document), "services", WithInferredTypes())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"services": []interface{}{
+				Parameters{"name": "web", "port": int64(80), "weight": 0.5, "enabled": true, "version": "1"},
+				Parameters{"name": "db", "port": int64(5432), "weight": 2.0, "enabled": false, "version": "1.2.3"},
+			},
+		}, got)
+	})
+
+	t.Run("header only", func(t *testing.T) {
+		got, err := FromCSV(strings.NewReader("name,port\n"), "services", WithInferredTypes())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"services": []interface{}{}}, got)
+	})
+
+	t.Run("duplicate column", func(t *testing.T) {
+		_, err := FromCSV(strings.NewReader("name,name\nweb,db\n"), "services")
+		assert.EqualError(t, err, "can't parse CSV: duplicate column 'name'")
+	})
+
+	t.Run("inconsistent row", func(t *testing.T) {
+		_, err := FromCSV(strings.NewReader("name,port\nweb\n"), "services")
+		assert.EqualError(t, err, "can't parse CSV: record on line 2: wrong number of fields")
+	})
+}
@@ -0,0 +1,168 @@
+package parameters
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StructTag is the struct field tag used to map the parameter keys to the struct fields,
+	// fields without the tag are matched by the (case insensitive) field name
+	StructTag = "param"
+)
+
+// DecodeOption mutates the Decode configuration
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	errorUnused bool
+}
+
+// WithErrorUnused makes Decode return an error when the parameters have keys
+// that do not match any of the struct fields
+func WithErrorUnused() DecodeOption {
+	return func(c *decodeConfig) {
+		c.errorUnused = true
+	}
+}
+
+// Decode populates the given struct pointer with the parameters, see also StructTag,
+// the nested parameters are decoded into the nested structs and the scalar values
+// are converted to the field types when possible (e.g. a float into an int)
+func (parameters Parameters) Decode(out interface{}, options ...DecodeOption) error {
+	var c decodeConfig
+	for _, option := range options {
+		option(&c)
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           out,
+		TagName:          StructTag,
+		WeaklyTypedInput: true,
+		ErrorUnused:      c.errorUnused,
+	})
+	if err != nil {
+		return errors.Wrap(err, "can't create a decoder")
+	}
+	err = decoder.Decode(parameters)
+	if err != nil {
+		return errors.Wrap(err, "can't decode the parameters")
+	}
+	return nil
+}
+
+// StructOption mutates the FromStruct configuration
+type StructOption func(*structConfig)
+
+type structConfig struct {
+	omitZero bool
+}
+
+// WithOmitZero makes FromStruct skip all the fields with the zero values, as if they had the 'omitempty' tag
+func WithOmitZero() StructOption {
+	return func(c *structConfig) {
+		c.omitZero = true
+	}
+}
+
+// FromStruct creates a configuration from a struct (or a pointer to a struct) and zero or more options
+// e.g. WithOmitZero, it is the inverse of Decode: the keys are the StructTag names (e.g. 'param:"db"'),
+// or else the field names, the nested structs and the maps become the nested parameters,
+// the fields tagged with '-' and the unexported fields are skipped, the ',omitempty' tag option skips
+// the field with the zero value and the ',squash' one merges the embedded struct fields into the parent.
+// The structs implementing encoding.TextMarshaler (e.g. time.Time) are kept as the values
+func FromStruct(v interface{}, options ...StructOption) (Parameters, error) {
+	var c structConfig
+	for _, option := range options {
+		option(&c)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, errors.Errorf("can't create parameters from type: '%T', expected a struct", v)
+	}
+	config := Parameters{}
+	c.structFields(config, value)
+	return config, nil
+}
+
+func (c structConfig) structFields(config Parameters, value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get(StructTag), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		name := field.Name
+		if tag[0] != "" {
+			name = tag[0]
+		}
+		omitEmpty, squash := c.omitZero, false
+		for _, option := range tag[1:] {
+			omitEmpty = omitEmpty || option == "omitempty"
+			squash = squash || option == "squash"
+		}
+
+		fieldValue := value.Field(i)
+		if omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		if embedded := reflect.Indirect(fieldValue); squash && embedded.Kind() == reflect.Struct {
+			c.structFields(config, embedded)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		config[name] = c.structValue(fieldValue)
+	}
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func (c structConfig) structValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return c.structValue(value.Elem())
+	case reflect.Struct:
+		if value.Type().Implements(textMarshalerType) || reflect.PtrTo(value.Type()).Implements(textMarshalerType) {
+			return value.Interface()
+		}
+		nested := Parameters{}
+		c.structFields(nested, value)
+		return nested
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			break
+		}
+		nested := Parameters{}
+		iterator := value.MapRange()
+		for iterator.Next() {
+			nested[iterator.Key().String()] = c.structValue(iterator.Value())
+		}
+		return nested
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		slice := make([]interface{}, value.Len())
+		for i := range slice {
+			slice[i] = c.structValue(value.Index(i))
+		}
+		return slice
+	}
+	return value.Interface()
+}
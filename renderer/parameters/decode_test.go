@@ -0,0 +1,153 @@
+package parameters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameters_Decode(t *testing.T) {
+	type database struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Name     string `param:"app_name"`
+		Replicas int
+		Ratio    float64
+		Database database `param:"db"`
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		params := Parameters{
+			"app_name": "render",
+			"db": Parameters{
+				"host": "localhost",
+				"port": 5432,
+			},
+		}
+
+		var got config
+		err := params.Decode(&got)
+		assert.NoError(t, err)
+		assert.Equal(t, config{
+			Name: "render",
+			Database: database{
+				Host: "localhost",
+				Port: 5432,
+			},
+		}, got)
+	})
+
+	t.Run("type coercion", func(t *testing.T) {
+		params := Parameters{
+			"replicas": float64(3),
+			"ratio":    "0.5",
+			"db": map[string]interface{}{
+				"port": "5432",
+			},
+		}
+
+		var got config
+		err := params.Decode(&got)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Replicas)
+		assert.Equal(t, 0.5, got.Ratio)
+		assert.Equal(t, 5432, got.Database.Port)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		params := Parameters{
+			"app_name":  "render",
+			"reeplicas": 3,
+		}
+
+		var lax config
+		err := params.Decode(&lax)
+		assert.NoError(t, err)
+
+		var strict config
+		err = params.Decode(&strict, WithErrorUnused())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "reeplicas")
+	})
+}
+
+func TestFromStruct(t *testing.T) {
+	type database struct {
+		Host string
+		Port int `param:"port"`
+	}
+	type common struct {
+		Env string `param:"env"`
+	}
+	type config struct {
+		common   `param:",squash"`
+		Name     string            `param:"app_name"`
+		Tags     []string          `param:"tags,omitempty"`
+		Database database          `param:"db"`
+		Replicas *int              `param:"replicas"`
+		Labels   map[string]string `param:"labels,omitempty"`
+		Released time.Time         `param:"released,omitempty"`
+		Ignored  string            `param:"-"`
+		internal string
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		replicas := 3
+		got, err := FromStruct(&config{
+			common:   common{Env: "prod"},
+			Name:     "render",
+			Tags:     []string{"a", "b"},
+			Database: database{Host: "localhost", Port: 5432},
+			Replicas: &replicas,
+			Labels:   map[string]string{"team": "core"},
+			Ignored:  "x",
+			internal: "y",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"env":      "prod",
+			"app_name": "render",
+			"tags":     []interface{}{"a", "b"},
+			"db":       Parameters{"Host": "localhost", "port": 5432},
+			"replicas": 3,
+			"labels":   Parameters{"team": "core"},
+		}, got)
+		host, _ := got.Get("db.Host")
+		assert.Equal(t, "localhost", host)
+
+		var decoded config
+		assert.NoError(t, got.Decode(&decoded))
+		assert.Equal(t, "render", decoded.Name)
+		assert.Equal(t, 5432, decoded.Database.Port)
+	})
+
+	t.Run("zero values", func(t *testing.T) {
+		got, err := FromStruct(config{Name: "render"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"env":      "",
+			"app_name": "render",
+			"db":       Parameters{"Host": "", "port": 0},
+			"replicas": nil,
+		}, got)
+
+		got, err = FromStruct(config{Name: "render"}, WithOmitZero())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"app_name": "render"}, got)
+	})
+
+	t.Run("text marshaler", func(t *testing.T) {
+		released := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+		got, err := FromStruct(config{Released: released}, WithOmitZero())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"released": released}, got)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		_, err := FromStruct(map[string]string{})
+		assert.EqualError(t, err, "can't create parameters from type: 'map[string]string', expected a struct")
+	})
+}
@@ -0,0 +1,105 @@
+package parameters
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// EnvVarRegexp defines the environment variable reference format used by ExpandEnv
+var EnvVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandOption mutates the ExpandEnv configuration
+type ExpandOption func(*expandConfig)
+
+type expandConfig struct {
+	allowUnset bool
+}
+
+// WithUnsetAsEmpty makes ExpandEnv expand the unset variables to empty strings instead of failing
+func WithUnsetAsEmpty() ExpandOption {
+	return func(c *expandConfig) {
+		c.allowUnset = true
+	}
+}
+
+// ExpandEnv returns a copy of the parameters with the '${VAR}' references in the string leaves
+// (also in the nested maps and slices) replaced with the values returned by the lookup function
+// (e.g. os.LookupEnv), an unset variable is an error unless WithUnsetAsEmpty is used
+func ExpandEnv(parameters Parameters, lookup func(string) (string, bool), options ...ExpandOption) (Parameters, error) {
+	var c expandConfig
+	for _, option := range options {
+		option(&c)
+	}
+	expanded, err := c.expandValue(parameters, "", lookup)
+	if err != nil {
+		return nil, err
+	}
+	return expanded.(Parameters), nil
+}
+
+func (c expandConfig) expandValue(value interface{}, key string, lookup func(string) (string, bool)) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return c.expandString(value, key, lookup)
+	case Parameters:
+		expanded, err := c.expandMap(value, key, lookup)
+		return Parameters(expanded), err
+	case map[string]interface{}:
+		return c.expandMap(value, key, lookup)
+	case []interface{}:
+		expanded := make([]interface{}, len(value))
+		for i, element := range value {
+			var err error
+			expanded[i], err = c.expandValue(element, key+"["+strconv.Itoa(i)+"]", lookup)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	case []string:
+		expanded := make([]string, len(value))
+		for i, element := range value {
+			var err error
+			expanded[i], err = c.expandString(element, key+"["+strconv.Itoa(i)+"]", lookup)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	default:
+		return value, nil
+	}
+}
+
+func (c expandConfig) expandMap(value map[string]interface{}, key string, lookup func(string) (string, bool)) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	expanded := make(map[string]interface{}, len(value))
+	for _, k := range sortedKeys(value) {
+		var err error
+		expanded[k], err = c.expandValue(value[k], joinKey(key, k), lookup)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+func (c expandConfig) expandString(value, key string, lookup func(string) (string, bool)) (string, error) {
+	var err error
+	expanded := EnvVarRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		name := EnvVarRegexp.FindStringSubmatch(reference)[1]
+		variable, ok := lookup(name)
+		if !ok && !c.allowUnset && err == nil {
+			err = errors.Errorf("unset variable '%s' in '%s'", name, key)
+		}
+		return variable
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
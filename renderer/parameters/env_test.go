@@ -0,0 +1,55 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{
+		"DB_HOST": "localhost",
+		"TAG":     "v1",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	t.Run("nested leaf", func(t *testing.T) {
+		params := Parameters{
+			"db":    Parameters{"url": "postgres://${DB_HOST}:5432", "port": 5432},
+			"plain": "$DB_HOST",
+		}
+		got, err := ExpandEnv(params, lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":    Parameters{"url": "postgres://localhost:5432", "port": 5432},
+			"plain": "$DB_HOST",
+		}, got)
+		assert.Equal(t, "postgres://${DB_HOST}:5432", params["db"].(Parameters)["url"], "should not be mutated")
+	})
+
+	t.Run("slice element", func(t *testing.T) {
+		got, err := ExpandEnv(Parameters{
+			"images": []interface{}{"render:${TAG}", 1},
+			"tags":   []string{"${TAG}"},
+		}, lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"images": []interface{}{"render:v1", 1},
+			"tags":   []string{"v1"},
+		}, got)
+	})
+
+	t.Run("unset variable", func(t *testing.T) {
+		params := Parameters{"db": Parameters{"password": "${DB_PASSWORD}"}}
+
+		_, err := ExpandEnv(params, lookup)
+		assert.EqualError(t, err, "unset variable 'DB_PASSWORD' in 'db.password'")
+
+		got, err := ExpandEnv(params, lookup, WithUnsetAsEmpty())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"password": ""}}, got)
+	})
+}
@@ -0,0 +1,286 @@
+package parameters
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/VirtusLab/go-extended/pkg/files"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// fileLoaders maps the file extensions to the loaders, see FromFile
+var fileLoaders = map[string]func(io.Reader) (Parameters, error){
+	".json": func(r io.Reader) (Parameters, error) { return FromJSON(r) },
+	".yaml": func(r io.Reader) (Parameters, error) { return FromYAML(r) },
+	".yml":  func(r io.Reader) (Parameters, error) { return FromYAML(r) },
+	".toml": func(r io.Reader) (Parameters, error) { return FromTOML(r) },
+
+	".properties": FromProperties,
+}
+
+// FromFile creates a configuration from a file, the format is selected by the file extension:
+// '.json', '.yaml', '.yml', '.toml', '.properties' or one registered with RegisterLoader
+func FromFile(path string) (Parameters, error) {
+	loader, err := fileLoader(path)
+	if err != nil {
+		return nil, err
+	}
+	err = files.CheckNotEmptyAndExists(path)
+	if err != nil {
+		logrus.Errorf("Can't find the configuration file '%s': %v", path, err)
+		return nil, errors.WithStack(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		logrus.Errorf("Can't open the configuration file '%s': %v", path, err)
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	config, err := loader(f)
+	if err != nil {
+		logrus.Errorf("Can't parse the configuration file '%s': %v", path, err)
+		return nil, errors.Wrapf(err, "can't parse the configuration file '%s'", path)
+	}
+	return config, nil
+}
+
+// FromFS creates a configuration from a file in the file system (e.g. an embed.FS),
+// the format is selected by the file extension, the same way as in FromFile
+func FromFS(fsys fs.FS, path string) (Parameters, error) {
+	loader, err := fileLoader(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fsys.Open(path)
+	if err != nil {
+		logrus.Errorf("Can't open the configuration file '%s': %v", path, err)
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	config, err := loader(f)
+	if err != nil {
+		logrus.Errorf("Can't parse the configuration file '%s': %v", path, err)
+		return nil, errors.Wrapf(err, "can't parse the configuration file '%s'", path)
+	}
+	return config, nil
+}
+
+var (
+	registeredLoadersMutex sync.RWMutex
+	registeredLoaders      = map[string]func(io.Reader) (Parameters, error){}
+)
+
+// RegisterLoader registers the loader for the file extension (e.g. '.ini', case insensitive),
+// used by FromFile, MergeFiles and FromFS, it returns an error if the extension
+// is already supported, by a built-in or a registered loader
+func RegisterLoader(ext string, fn func(io.Reader) (Parameters, error)) error {
+	if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+		return errors.Errorf("invalid file extension: '%s', must start with '.'", ext)
+	}
+	if fn == nil {
+		return errors.Errorf("unexpected nil loader for the file extension: '%s'", ext)
+	}
+	ext = strings.ToLower(ext)
+
+	registeredLoadersMutex.Lock()
+	defer registeredLoadersMutex.Unlock()
+	_, builtIn := fileLoaders[ext]
+	_, registered := registeredLoaders[ext]
+	if builtIn || registered {
+		return errors.Errorf("loader for the file extension '%s' is already registered", ext)
+	}
+	registeredLoaders[ext] = fn
+	return nil
+}
+
+// unregisterLoader removes the loader registered for the file extension, e.g. to restore the state after a test
+func unregisterLoader(ext string) {
+	registeredLoadersMutex.Lock()
+	defer registeredLoadersMutex.Unlock()
+	delete(registeredLoaders, strings.ToLower(ext))
+}
+
+func fileLoader(path string) (func(io.Reader) (Parameters, error), error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if loader, ok := fileLoaders[ext]; ok {
+		return loader, nil
+	}
+	registeredLoadersMutex.RLock()
+	loader, ok := registeredLoaders[ext]
+	registeredLoadersMutex.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unsupported configuration file extension: '%s'", path)
+	}
+	return loader, nil
+}
+
+// MergeFiles creates a configuration from one or more configuration file paths, see FromFile,
+// the later files override the earlier ones
+func MergeFiles(paths ...string) (Parameters, error) {
+	config, _, err := MergeFilesTracked(paths...)
+	return config, err
+}
+
+// MergeFilesTracked creates a configuration like MergeFiles and returns a map
+// from every flattened key (see Flatten) to the path of the file that set its value
+func MergeFilesTracked(paths ...string) (Parameters, map[string]string, error) {
+	var accumulator = Parameters{}
+	origins := map[string]string{}
+	for i, path := range paths {
+		logrus.Debugf("Reading configuration file [%d]: %v", i, path)
+		config, err := FromFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		accumulator, err = Merge(accumulator, config)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "can't merge the configuration file '%s'", path)
+		}
+		for key := range config.Flatten() {
+			origins[key] = path
+		}
+	}
+
+	// drop the keys overridden by a shorter slice or a scalar
+	flat := accumulator.Flatten()
+	for key := range origins {
+		if _, ok := flat[key]; !ok {
+			delete(origins, key)
+		}
+	}
+	logrus.Debugf("Parameters from files: %v", accumulator)
+	return accumulator, origins, nil
+}
+
+const (
+	// DefaultsKey is the section with the defaults for all the environments, see LoadEnvScoped
+	DefaultsKey = "defaults"
+	// EnvironmentsKey is the section with the per environment overrides, see LoadEnvScoped
+	EnvironmentsKey = "environments"
+)
+
+// LoadEnvScoped loads the configuration file (see FromFile) with the environment scoped sections
+// and returns the 'defaults' section merged with the 'environments.<env>' section,
+// only the defaults are returned if there is no section for the environment
+func LoadEnvScoped(path, env string) (Parameters, error) {
+	config, err := FromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := envSection(config, DefaultsKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration file '%s'", path)
+	}
+	environments, err := envSection(config, EnvironmentsKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration file '%s'", path)
+	}
+	overrides, err := envSection(environments, env)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration file '%s'", path)
+	}
+	if len(overrides) == 0 {
+		logrus.Debugf("No '%s' environment section in '%s', using the defaults", env, path)
+	}
+
+	result, err := Merge(defaults, overrides)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't merge the '%s' environment section in '%s'", env, path)
+	}
+	return result, nil
+}
+
+// envSection returns the map under the key, or an empty parameters if the key is missing
+func envSection(config Parameters, key string) (Parameters, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return Parameters{}, nil
+	}
+	section, ok := asMap(value)
+	if !ok {
+		return nil, errors.Errorf("section '%s' must be a map, it has type: '%s'", key, reflect.TypeOf(value))
+	}
+	return Parameters(section), nil
+}
+
+// IncludeKey is the key listing the files included by a configuration file, see FromFileWithIncludes
+const IncludeKey = "_include"
+
+// FromFileWithIncludes creates a configuration from a file like FromFile, and resolves the '_include' key,
+// a path or a list of paths relative to the including file, the included files (and their includes, recursively)
+// are merged in the listed order and the including file is merged last, so it overrides them,
+// a circular include is an error
+func FromFileWithIncludes(path string) (Parameters, error) {
+	return fromFileWithIncludes(path, nil)
+}
+
+func fromFileWithIncludes(path string, stack []string) (Parameters, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get an absolute path for: '%s'", path)
+	}
+	for i, including := range stack {
+		if including == absolute {
+			cycle := append(stack[i:len(stack):len(stack)], absolute)
+			return nil, errors.Errorf("circular include: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack[:len(stack):len(stack)], absolute)
+
+	config, err := FromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := includePaths(config[IncludeKey])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid '%s' in '%s'", IncludeKey, path)
+	}
+	delete(config, IncludeKey)
+
+	var accumulator = Parameters{}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		logrus.Debugf("Including configuration file '%s' in '%s'", include, path)
+		included, err := fromFileWithIncludes(include, stack)
+		if err != nil {
+			return nil, err
+		}
+		accumulator, err = Merge(accumulator, included)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't merge the included file '%s'", include)
+		}
+	}
+	return Merge(accumulator, config)
+}
+
+func includePaths(value interface{}) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		paths := make([]string, len(value))
+		for i, element := range value {
+			path, ok := element.(string)
+			if !ok {
+				return nil, errors.Errorf("include path must be a string, it has type: '%s'", reflect.TypeOf(element))
+			}
+			paths[i] = path
+		}
+		return paths, nil
+	default:
+		return nil, errors.Errorf("must be a path or a list of paths, it has type: '%s'", reflect.TypeOf(value))
+	}
+}
@@ -0,0 +1,235 @@
+package parameters
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFilesTracked(t *testing.T) {
+	t.Run("two files", func(t *testing.T) {
+		got, origins, err := MergeFilesTracked("testdata/base.yaml", "testdata/prod.json")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"name": "render",
+			"db": Parameters{
+				"host": "prod.example.com",
+				"port": 5432,
+			},
+			"tags": []interface{}{"prod"},
+		}, got)
+		assert.Equal(t, map[string]string{
+			"name":    "testdata/base.yaml",
+			"db.host": "testdata/prod.json",
+			"db.port": "testdata/base.yaml",
+			"tags[0]": "testdata/prod.json",
+		}, origins)
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := MergeFiles("testdata/base.ini")
+		assert.EqualError(t, err, "unsupported configuration file extension: 'testdata/base.ini'")
+	})
+}
+
+func TestRegisterLoader(t *testing.T) {
+	confLoader := func(r io.Reader) (Parameters, error) {
+		config := Parameters{}
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if kv := strings.SplitN(scanner.Text(), "=", 2); len(kv) == 2 {
+				if err := config.Set(kv[0], kv[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return config, scanner.Err()
+	}
+	assert.NoError(t, RegisterLoader(".conf", confLoader))
+	t.Cleanup(func() { unregisterLoader(".conf") })
+
+	t.Run("dispatched", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "override.CONF")
+		assert.NoError(t, os.WriteFile(path, []byte("db.host=conf.example.com\n"), 0644))
+
+		got, err := MergeFiles("testdata/base.yaml", path)
+		assert.NoError(t, err)
+		assert.Equal(t, "conf.example.com", got["db"].(Parameters)["host"])
+		assert.Equal(t, 5432, got["db"].(Parameters)["port"])
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := RegisterLoader(".CONF", confLoader)
+		assert.EqualError(t, err, "loader for the file extension '.conf' is already registered")
+	})
+
+	t.Run("built-in", func(t *testing.T) {
+		err := RegisterLoader(".yaml", confLoader)
+		assert.EqualError(t, err, "loader for the file extension '.yaml' is already registered")
+	})
+
+	t.Run("invalid extension", func(t *testing.T) {
+		err := RegisterLoader("conf", confLoader)
+		assert.EqualError(t, err, "invalid file extension: 'conf', must start with '.'")
+	})
+}
+
+func TestParameters_Flatten(t *testing.T) {
+	params := Parameters{
+		"a": Parameters{
+			"b":     1,
+			"empty": Parameters{},
+		},
+		"list": []interface{}{
+			Parameters{"name": "first"},
+			"second",
+		},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"a.b":          1,
+		"a.empty":      Parameters{},
+		"list[0].name": "first",
+		"list[1]":      "second",
+	}, params.Flatten())
+}
+
+func TestUnflatten(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		params := Parameters{
+			"a":    Parameters{"b": 1, "empty": Parameters{}, "c": Parameters{"d": "x"}},
+			"list": []interface{}{Parameters{"name": "first", "tags": []interface{}{"t"}}, "second"},
+		}
+		got, err := Unflatten(params.Flatten())
+		assert.NoError(t, err)
+		assert.Equal(t, params, got)
+	})
+
+	t.Run("slice from bracket keys", func(t *testing.T) {
+		got, err := Unflatten(map[string]interface{}{
+			"servers[1].host": "db",
+			"servers[0].host": "web",
+			"ports[2]":        443,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"servers": []interface{}{Parameters{"host": "web"}, Parameters{"host": "db"}},
+			"ports":   []interface{}{nil, nil, 443},
+		}, got)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := Unflatten(map[string]interface{}{"db": "postgres", "db.host": "localhost"})
+		assert.EqualError(t, err, "can't unflatten key 'db.host': "+
+			"key conflict: key 'host' can't be set, the value is not a map, it has type: 'string'")
+	})
+}
+
+func TestLoadEnvScoped(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		expected Parameters
+	}{
+		{
+			name: "prod overrides",
+			env:  "prod",
+			expected: Parameters{
+				"replicas": 3,
+				"db":       Parameters{"host": "prod.example.com", "port": 5432},
+			},
+		},
+		{
+			name: "nested override",
+			env:  "staging",
+			expected: Parameters{
+				"replicas": 1,
+				"db":       Parameters{"host": "localhost", "port": 6432},
+			},
+		},
+		{
+			name: "unknown environment",
+			env:  "dev",
+			expected: Parameters{
+				"replicas": 1,
+				"db":       Parameters{"host": "localhost", "port": 5432},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadEnvScoped("testdata/environments.yaml", tt.env)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("invalid section", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("defaults: none\n"), 0644))
+		_, err := LoadEnvScoped(path, "prod")
+		assert.EqualError(t, err, "invalid configuration file '"+path+"': section 'defaults' must be a map, it has type: 'string'")
+	})
+}
+
+func TestFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/base.yaml": {Data: []byte("db:\n  host: localhost\n  port: 5432\n")},
+		"config/prod.json": {Data: []byte(`{"db": {"host": "prod.example.com"}}`)},
+		"config/base.ini":  {Data: []byte("[db]\nhost=localhost\n")},
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		got, err := FromFS(fsys, "config/base.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost", "port": 5432}}, got)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		got, err := FromFS(fsys, "config/prod.json")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "prod.example.com"}}, got)
+	})
+
+	t.Run("unknown extension", func(t *testing.T) {
+		_, err := FromFS(fsys, "config/base.ini")
+		assert.EqualError(t, err, "unsupported configuration file extension: 'config/base.ini'")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := FromFS(fsys, "config/missing.yaml")
+		assert.Error(t, err)
+	})
+}
+
+func TestFromFileWithIncludes(t *testing.T) {
+	t.Run("single include", func(t *testing.T) {
+		got, err := FromFileWithIncludes("testdata/include/nested/app.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"name": "app", "debug": false, "replicas": 2}, got)
+	})
+
+	t.Run("nested includes", func(t *testing.T) {
+		got, err := FromFileWithIncludes("testdata/include/main.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"name":     "main",
+			"debug":    false,
+			"replicas": 2,
+			"db":       Parameters{"host": "localhost", "port": 6432},
+		}, got)
+	})
+
+	t.Run("circular include", func(t *testing.T) {
+		_, err := FromFileWithIncludes("testdata/include/cycle-a.yaml")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "circular include: ")
+		assert.Regexp(t, `cycle-a.yaml -> .*cycle-b.yaml -> .*cycle-a.yaml$`, err.Error())
+	})
+}
@@ -0,0 +1,86 @@
+package parameters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// FlatStringOption mutates the ToFlatMapString configuration
+type FlatStringOption func(*flatStringConfig)
+
+type flatStringConfig struct {
+	jsonComplex bool
+}
+
+// WithJSONComplexValues makes ToFlatMapString JSON encode the leaves that are not scalars
+// (e.g. the empty maps and slices), instead of returning an error
+func WithJSONComplexValues() FlatStringOption {
+	return func(c *flatStringConfig) {
+		c.jsonComplex = true
+	}
+}
+
+// ToFlatMapString flattens the parameters (see Flatten) into the strings, the strings are kept,
+// the numbers, the booleans and the fmt.Stringer values are formatted with fmt and nil is an empty string,
+// any other leaf (e.g. an empty map) is an error, unless WithJSONComplexValues is used
+func (parameters Parameters) ToFlatMapString(options ...FlatStringOption) (map[string]string, error) {
+	var c flatStringConfig
+	for _, option := range options {
+		option(&c)
+	}
+
+	flat := parameters.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	stringified := make(map[string]string, len(flat))
+	for _, key := range keys {
+		value, err := c.stringify(flat[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't stringify key '%s'", key)
+		}
+		stringified[key] = value
+	}
+	return stringified, nil
+}
+
+func (c flatStringConfig) stringify(value interface{}) (string, error) {
+	_, isMap := asMap(value)
+	_, isSlice := value.([]interface{})
+	if isMap || isSlice {
+		return c.complex(value)
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case fmt.Stringer:
+		return value.String(), nil
+	}
+	if _, _, ok := asNumber(value); ok {
+		return fmt.Sprint(value), nil
+	}
+	return c.complex(value)
+}
+
+func (c flatStringConfig) complex(value interface{}) (string, error) {
+	if !c.jsonComplex {
+		return "", errors.Errorf("unexpected complex value of type: '%T'", value)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrap(err, "can't serialize to JSON")
+	}
+	return string(b), nil
+}
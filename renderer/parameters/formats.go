@@ -0,0 +1,493 @@
+package parameters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// JSONFormat is the JSON serialization format name
+	JSONFormat = "json"
+	// YAMLFormat is the YAML serialization format name
+	YAMLFormat = "yaml"
+	// TOMLFormat is the TOML serialization format name
+	TOMLFormat = "toml"
+)
+
+var serializers = map[string]func(Parameters) ([]byte, error){
+	JSONFormat: ToJSON,
+	YAMLFormat: ToYAML,
+	TOMLFormat: ToTOML,
+}
+
+var deserializers = map[string]func(io.Reader) (Parameters, error){
+	JSONFormat: func(r io.Reader) (Parameters, error) { return FromJSON(r) },
+	YAMLFormat: func(r io.Reader) (Parameters, error) { return FromYAML(r) },
+	TOMLFormat: func(r io.Reader) (Parameters, error) { return FromTOML(r) },
+}
+
+// LoadOption mutates the loader (e.g. FromYAML) configuration
+type LoadOption func(*loadConfig)
+
+type loadConfig struct {
+	rejectMergeKeys  bool
+	normalizeNumbers bool
+	whenGuards       bool
+	whenContext      Parameters
+	unitScalars      bool
+}
+
+func newLoadConfig(options ...LoadOption) loadConfig {
+	var c loadConfig
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// WithRejectMergeKeys makes FromYAML return an error when the document uses the merge keys ('<<'),
+// by default the merge keys are resolved
+func WithRejectMergeKeys() LoadOption {
+	return func(c *loadConfig) {
+		c.rejectMergeKeys = true
+	}
+}
+
+// Formats returns the sorted names of the supported serialization formats
+func Formats() []string {
+	var formats []string
+	for format := range serializers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Serialize turns the parameters into the given format, see also Formats,
+// the output is deterministic (the keys are sorted)
+func Serialize(parameters Parameters, format string) ([]byte, error) {
+	serializer, ok := serializers[format]
+	if !ok {
+		return nil, errors.Errorf("unsupported format: '%s', format must be in: '%s'",
+			format, strings.Join(Formats(), ", "))
+	}
+	return serializer(parameters)
+}
+
+// Deserialize creates a configuration from the data in the given format, see also Formats
+func Deserialize(data []byte, format string) (Parameters, error) {
+	deserializer, ok := deserializers[format]
+	if !ok {
+		return nil, errors.Errorf("unsupported format: '%s', format must be in: '%s'",
+			format, strings.Join(Formats(), ", "))
+	}
+	return deserializer(bytes.NewReader(data))
+}
+
+// FromMap creates a configuration from a map, the nested maps are converted to Parameters
+// (the non-string keys are formatted as strings)
+func FromMap(m map[string]interface{}) Parameters {
+	parameters := make(Parameters, len(m))
+	for k, v := range m {
+		parameters[k] = fromValue(v)
+	}
+	return parameters
+}
+
+func fromValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case Parameters:
+		return FromMap(value)
+	case map[string]interface{}:
+		return FromMap(value)
+	case map[interface{}]interface{}:
+		converted := make(Parameters, len(value))
+		for k, v := range value {
+			converted[fmt.Sprint(k)] = fromValue(v)
+		}
+		return converted
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = FromMap(element)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = fromValue(element)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// Map returns a copy of the parameters as a plain map, the nested Parameters
+// (also inside of slices) are converted to plain maps, it is the inverse of FromMap
+func (parameters Parameters) Map() map[string]interface{} {
+	if parameters == nil {
+		return nil
+	}
+	return toMap(parameters)
+}
+
+func toMap(m map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted[k] = toValue(v)
+	}
+	return converted
+}
+
+func toValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case Parameters:
+		return toMap(value)
+	case map[string]interface{}:
+		return toMap(value)
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			converted[i] = toValue(element)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// WithUnitScalars makes FromYAML, FromJSON and FromTOML coerce the strings exactly matching a duration
+// (e.g. '30s' or '1h30m') to time.Duration and a byte size with an explicit unit (e.g. '5Mi', '5MiB' or '5MB')
+// to the number of bytes (int64), see CoerceUnitScalars
+func WithUnitScalars() LoadOption {
+	return func(c *loadConfig) {
+		c.unitScalars = true
+	}
+}
+
+// resolve applies the options common to all the loaders to the loaded configuration
+func (c loadConfig) resolve(config Parameters) (Parameters, error) {
+	resolved, _, err := c.resolveMarked(config, nil)
+	return resolved, err
+}
+
+// resolveMarked applies the options like resolve and returns the dotted keys the marked keys have
+// in the resolved configuration, the guards (see WithWhenGuards) can move or drop the marked keys
+func (c loadConfig) resolveMarked(config Parameters, marked []string) (Parameters, []string, error) {
+	if c.whenGuards {
+		resolved, moved, err := resolveWhenMarked(config, c.whenContext, marked)
+		if err != nil {
+			return nil, nil, err
+		}
+		config, marked = resolved, moved
+	}
+	if c.unitScalars {
+		config = CoerceUnitScalars(config)
+	}
+	return config, marked, nil
+}
+
+// WithNormalizedNumbers makes FromJSON convert the integral numbers to int64, see NormalizeNumbers
+func WithNormalizedNumbers() LoadOption {
+	return func(c *loadConfig) {
+		c.normalizeNumbers = true
+	}
+}
+
+// FromJSON creates a configuration from a JSON document and zero or more options
+// e.g. WithNormalizedNumbers, by default all the numbers are float64
+func FromJSON(r io.Reader, options ...LoadOption) (Parameters, error) {
+	c := newLoadConfig(options...)
+
+	var config map[string]interface{}
+	err := json.NewDecoder(r).Decode(&config)
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "can't parse JSON")
+	}
+	loaded, err := c.resolve(FromMap(config))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse JSON")
+	}
+	if c.normalizeNumbers {
+		return NormalizeNumbers(loaded), nil
+	}
+	return loaded, nil
+}
+
+// NormalizeNumbers returns a copy of the parameters with the integral float64 values
+// (e.g. 3.0, as decoded from JSON) converted to int64, also in the nested maps and slices,
+// the fractional values are left as they are
+func NormalizeNumbers(parameters Parameters) Parameters {
+	normalized := parameters.Clone()
+	normalizeNumbers(normalized)
+	return normalized
+}
+
+func normalizeNumbers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt64 && value < math.MaxInt64 {
+			return int64(value)
+		}
+		return value
+	case Parameters:
+		for _, k := range sortedKeys(value) {
+			value[k] = normalizeNumbers(value[k])
+		}
+		return value
+	case map[string]interface{}:
+		for _, k := range sortedKeys(value) {
+			value[k] = normalizeNumbers(value[k])
+		}
+		return value
+	case []interface{}:
+		for i, element := range value {
+			value[i] = normalizeNumbers(element)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// FromYAML creates a configuration from a YAML document and zero or more options
+// e.g. WithRejectMergeKeys or WithWhenGuards, the aliases are resolved into full copies of the anchored values,
+// the '!secret' tags are removed, see FromYAMLAnnotated
+func FromYAML(r io.Reader, options ...LoadOption) (Parameters, error) {
+	config, err := FromYAMLAnnotated(r, options...)
+	return config.Parameters, err
+}
+
+// FromYAMLAnnotated creates a configuration from a YAML document like FromYAML,
+// the keys of the values tagged with '!secret' are marked as secrets in the metadata, see SecretTag
+func FromYAMLAnnotated(r io.Reader, options ...LoadOption) (Annotated, error) {
+	config, err := decodeYAML(yaml.NewDecoder(r), newLoadConfig(options...))
+	if err == io.EOF {
+		return NewAnnotated(Parameters{}), nil
+	}
+	return config, err
+}
+
+// FromYAMLStreamFold creates a configuration from a multi-document YAML stream, decoding one document at a time
+// and folding it into the accumulated configuration, so only the current document is kept in memory,
+// the fold is Merge if nil, the options are the same as in FromYAML
+func FromYAMLStreamFold(r io.Reader, fold func(acc, doc Parameters) (Parameters, error), options ...LoadOption) (Parameters, error) {
+	if fold == nil {
+		fold = func(acc, doc Parameters) (Parameters, error) {
+			return Merge(acc, doc)
+		}
+	}
+	c := newLoadConfig(options...)
+
+	decoder := yaml.NewDecoder(r)
+	accumulator := Parameters{}
+	for i := 0; ; i++ {
+		document, err := decodeYAML(decoder, c)
+		if err == io.EOF {
+			return accumulator, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read YAML document %d", i)
+		}
+		accumulator, err = fold(accumulator, document.Parameters)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't fold YAML document %d", i)
+		}
+	}
+}
+
+// decodeYAML decodes the next document, it returns io.EOF at the end of the stream
+func decodeYAML(decoder *yaml.Decoder, c loadConfig) (Annotated, error) {
+	var document yaml.Node
+	err := decoder.Decode(&document)
+	if err == io.EOF {
+		return Annotated{}, err
+	}
+	if err != nil {
+		return Annotated{}, errors.Wrap(err, "can't parse YAML")
+	}
+
+	if c.rejectMergeKeys {
+		if line, found := findMergeKey(&document); found {
+			return Annotated{}, errors.Errorf("can't parse YAML: merge keys are not allowed, found at line %d", line)
+		}
+	}
+
+	secrets := stripSecretTags(&document, nil)
+
+	var config map[string]interface{}
+	err = document.Decode(&config)
+	if err != nil {
+		return Annotated{}, errors.Wrap(err, "can't parse YAML")
+	}
+	loaded, secrets, err := c.resolveMarked(FromMap(config), secrets)
+	if err != nil {
+		return Annotated{}, errors.Wrap(err, "can't parse YAML")
+	}
+	result := NewAnnotated(loaded)
+	for _, secret := range secrets {
+		result.Metadata.MarkSecret(secret)
+	}
+	return result, nil
+}
+
+// FromYAMLStrict creates a configuration from a YAML document like FromYAML, but returns an error
+// if the document has a flattened key (see Flatten) not allowed by any of the known keys.
+// A known key allows itself and all the keys nested under it, its segments can use
+// the path.Match patterns, e.g. 'servers.*.port' allows 'servers.web.port' and 'servers[0].port'
+func FromYAMLStrict(r io.Reader, knownKeys []string, options ...LoadOption) (Parameters, error) {
+	config, err := FromYAML(r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for key := range config.Flatten() {
+		if !isKnownKey(key, knownKeys) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("unknown keys: '%s'", strings.Join(unknown, "', '"))
+	}
+	return config, nil
+}
+
+func isKnownKey(key string, knownKeys []string) bool {
+	segments := keySegments(key)
+	for _, known := range knownKeys {
+		knownSegments := strings.Split(known, ".")
+		if len(knownSegments) > len(segments) {
+			continue
+		}
+		matched := true
+		for i, knownSegment := range knownSegments {
+			if ok, _ := path.Match(knownSegment, segments[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// keySegments splits a flattened key into segments, the bracket indices are separate segments
+func keySegments(key string) []string {
+	key = strings.ReplaceAll(key, "[", ".")
+	key = strings.ReplaceAll(key, "]", "")
+	return strings.Split(key, ".")
+}
+
+// SecretTag is the YAML tag marking the secret values, e.g. 'password: !secret hunter2',
+// the tag is removed and the key is marked as a secret (see Metadata.MarkSecret) by FromYAMLAnnotated
+const SecretTag = "!secret"
+
+// stripSecretTags removes the secret tags from the nodes and returns the dotted keys of the tagged ones,
+// the slice elements use the numeric segments (e.g. 'users.0.password'), see Get
+func stripSecretTags(node *yaml.Node, path []string) []string {
+	var secrets []string
+	if node.Tag == SecretTag {
+		node.Tag = ""
+		if len(path) > 0 {
+			secrets = append(secrets, strings.Join(path, "."))
+		}
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			secrets = append(secrets, stripSecretTags(child, path)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := append(path[:len(path):len(path)], node.Content[i].Value)
+			secrets = append(secrets, stripSecretTags(node.Content[i+1], keyPath)...)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			secrets = append(secrets, stripSecretTags(child, append(path[:len(path):len(path)], strconv.Itoa(i)))...)
+		}
+	}
+	return secrets
+}
+
+// findMergeKey returns the line of the first merge key ('<<') in the document
+func findMergeKey(node *yaml.Node) (int, bool) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Tag == "!!merge" {
+				return key.Line, true
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if line, found := findMergeKey(child); found {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+// FromTOML creates a configuration from a TOML document and zero or more options e.g. WithUnitScalars
+func FromTOML(r io.Reader, options ...LoadOption) (Parameters, error) {
+	var config map[string]interface{}
+	_, err := toml.NewDecoder(r).Decode(&config)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse TOML")
+	}
+	loaded, err := newLoadConfig(options...).resolve(FromMap(config))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse TOML")
+	}
+	return loaded, nil
+}
+
+// ToJSON turns the parameters into an indented JSON document
+func ToJSON(parameters Parameters) ([]byte, error) {
+	b, err := json.MarshalIndent(parameters, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to JSON")
+	}
+	return append(b, '\n'), nil
+}
+
+// ToYAML turns the parameters into a YAML document
+func ToYAML(parameters Parameters) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	err := encoder.Encode(parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to YAML")
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to YAML")
+	}
+	return b.Bytes(), nil
+}
+
+// ToTOML turns the parameters into a TOML document
+func ToTOML(parameters Parameters) ([]byte, error) {
+	var b bytes.Buffer
+	err := toml.NewEncoder(&b).Encode(parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't serialize to TOML")
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,285 @@
+package parameters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerialize(t *testing.T) {
+	params := Parameters{
+		"name":    "render",
+		"enabled": true,
+		"ratio":   0.5,
+		"db": Parameters{
+			"host": "localhost",
+			"tags": []interface{}{"a", "b"},
+		},
+	}
+
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			first, err := Serialize(params, format)
+			assert.NoError(t, err)
+			second, err := Serialize(params, format)
+			assert.NoError(t, err)
+			assert.Equal(t, string(first), string(second), "should be deterministic")
+
+			got, err := Deserialize(first, format)
+			assert.NoError(t, err)
+			assert.Equal(t, params, got)
+		})
+	}
+
+	t.Run("yaml output", func(t *testing.T) {
+		got, err := Serialize(Parameters{"b": 1, "a": Parameters{"c": "x"}}, YAMLFormat)
+		assert.NoError(t, err)
+		assert.Equal(t, "a:\n  c: x\nb: 1\n", string(got))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Serialize(params, "xml")
+		assert.EqualError(t, err, "unsupported format: 'xml', format must be in: 'json, toml, yaml'")
+	})
+}
+
+func TestFromYAML_Aliases(t *testing.T) {
+	t.Run("alias expanded", func(t *testing.T) {
+		document := `
+base: &base
+  image: render
+  ports: [80]
+web: *base
+`
+		got, err := FromYAML(strings.NewReader(document))
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"base": Parameters{"image": "render", "ports": []interface{}{80}},
+			"web":  Parameters{"image": "render", "ports": []interface{}{80}},
+		}, got)
+
+		got["web"].(Parameters)["image"] = "changed"
+		assert.Equal(t, "render", got["base"].(Parameters)["image"], "alias should be a copy")
+	})
+
+	mergeKeys := `
+base: &base
+  image: render
+web:
+  <<: *base
+  port: 80
+`
+
+	t.Run("merge keys resolved", func(t *testing.T) {
+		got, err := FromYAML(strings.NewReader(mergeKeys))
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"base": Parameters{"image": "render"},
+			"web":  Parameters{"image": "render", "port": 80},
+		}, got)
+	})
+
+	t.Run("merge keys rejected", func(t *testing.T) {
+		got, err := FromYAML(strings.NewReader(mergeKeys), WithRejectMergeKeys())
+		assert.EqualError(t, err, "can't parse YAML: merge keys are not allowed, found at line 5")
+		assert.Nil(t, got)
+	})
+}
+
+func TestParameters_Map(t *testing.T) {
+	params := Parameters{
+		"a": Parameters{
+			"b": map[string]interface{}{"c": Parameters{"d": 1}},
+		},
+		"list": []interface{}{Parameters{"e": "x"}, "y"},
+	}
+
+	got := params.Map()
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": map[string]interface{}{"d": 1}},
+		},
+		"list": []interface{}{map[string]interface{}{"e": "x"}, "y"},
+	}, got)
+
+	got["a"].(map[string]interface{})["b"] = "changed"
+	got["list"].([]interface{})[1] = "changed"
+	assert.Equal(t, Parameters{
+		"a": Parameters{
+			"b": map[string]interface{}{"c": Parameters{"d": 1}},
+		},
+		"list": []interface{}{Parameters{"e": "x"}, "y"},
+	}, params, "the original should not be mutated")
+
+	assert.Equal(t, FromMap(params), FromMap(params.Map()))
+}
+
+func TestFromYAMLStrict(t *testing.T) {
+	known := []string{"name", "deployment.replicas", "servers.*.port", "labels"}
+
+	t.Run("unknown key", func(t *testing.T) {
+		document := `
+name: render
+deployment:
+  reeplicas: 3
+  replicas: 3
+`
+		got, err := FromYAMLStrict(strings.NewReader(document), known)
+		assert.EqualError(t, err, "unknown keys: 'deployment.reeplicas'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("known nested key", func(t *testing.T) {
+		document := `
+deployment:
+  replicas: 3
+labels:
+  app: render
+`
+		got, err := FromYAMLStrict(strings.NewReader(document), known)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"deployment": Parameters{"replicas": 3},
+			"labels":     Parameters{"app": "render"},
+		}, got)
+	})
+
+	t.Run("wildcard key", func(t *testing.T) {
+		document := `
+servers:
+  web:
+    port: 80
+  db:
+    port: 5432
+    host: localhost
+`
+		_, err := FromYAMLStrict(strings.NewReader(document), known)
+		assert.EqualError(t, err, "unknown keys: 'servers.db.host'")
+
+		_, err = FromYAMLStrict(strings.NewReader(document), append(known, "servers.db.host"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestNormalizeNumbers(t *testing.T) {
+	document := `{
+	"replicas": 3.0,
+	"ratio": 3.5,
+	"nested": {"port": 8080, "weights": [1, 1.5, {"limit": 10}]}
+}`
+
+	t.Run("default", func(t *testing.T) {
+		got, err := FromJSON(strings.NewReader(document))
+		assert.NoError(t, err)
+		assert.Equal(t, float64(3), got["replicas"])
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		got, err := FromJSON(strings.NewReader(document), WithNormalizedNumbers())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"replicas": int64(3),
+			"ratio":    3.5,
+			"nested": Parameters{
+				"port":    int64(8080),
+				"weights": []interface{}{int64(1), 1.5, Parameters{"limit": int64(10)}},
+			},
+		}, got)
+	})
+
+	t.Run("not mutated", func(t *testing.T) {
+		params := Parameters{"replicas": 3.0, "list": []interface{}{2.0}}
+		got := NormalizeNumbers(params)
+		assert.Equal(t, Parameters{"replicas": int64(3), "list": []interface{}{int64(2)}}, got)
+		assert.Equal(t, Parameters{"replicas": 3.0, "list": []interface{}{2.0}}, params)
+	})
+}
+
+func TestFromYAMLStreamFold(t *testing.T) {
+	stream := `db:
+  host: localhost
+  port: 5432
+---
+db:
+  host: remote
+---
+debug: true
+`
+
+	t.Run("default merge", func(t *testing.T) {
+		got, err := FromYAMLStreamFold(strings.NewReader(stream), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":    Parameters{"host": "remote", "port": 5432},
+			"debug": true,
+		}, got)
+	})
+
+	t.Run("custom fold", func(t *testing.T) {
+		got, err := FromYAMLStreamFold(strings.NewReader(stream), func(acc, doc Parameters) (Parameters, error) {
+			count, _ := acc["documents"].(int)
+			acc["documents"] = count + 1
+			return acc, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"documents": 3}, got)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		_, err := FromYAMLStreamFold(strings.NewReader("a: 1\n---\n: b: c\n"), nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "can't read YAML document 1")
+	})
+}
+
+func TestFromYAML_WhenGuards(t *testing.T) {
+	context := Parameters{"env": "prod"}
+
+	t.Run("included when true", func(t *testing.T) {
+		document := `
+env: prod
+replicas:
+  value: 5
+  when: env == prod
+hosts:
+  - web
+  - value: debug
+    when: env != "prod"
+`
+		got, err := FromYAML(strings.NewReader(document), WithWhenGuards(context))
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"env": "prod", "replicas": 5, "hosts": []interface{}{"web"}}, got)
+	})
+
+	t.Run("dropped when false", func(t *testing.T) {
+		document := `
+db:
+  host: localhost
+  debug:
+    value: true
+    when: "env == dev"
+`
+		got, err := FromYAML(strings.NewReader(document), WithWhenGuards(context))
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost"}}, got)
+	})
+
+	t.Run("malformed guard", func(t *testing.T) {
+		document := `
+replicas:
+  value: 5
+  when: env = prod
+`
+		got, err := FromYAML(strings.NewReader(document), WithWhenGuards(context))
+		assert.EqualError(t, err, "can't parse YAML: invalid 'when' guard of 'replicas': "+
+			"expected '<key> == <literal>' or '<key> != <literal>', got: 'env = prod'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("without option", func(t *testing.T) {
+		got, err := FromJSON(strings.NewReader(`{"replicas": {"value": 5, "when": "env == dev"}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"replicas": Parameters{"value": 5.0, "when": "env == dev"}}, got)
+	})
+}
@@ -0,0 +1,145 @@
+package parameters
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SetGlob sets the value on every existing key matching the dotted pattern
+// and returns the number of keys set, e.g. 'servers.*.enabled' sets 'enabled'
+// on every map under 'servers'. Each pattern segment is matched against a single key
+// using the path.Match syntax, only the last segment can create a new key
+// and only if it is not a pattern. A pattern that matches nothing is not an error.
+func SetGlob(parameters *Parameters, pattern string, value interface{}) (int, error) {
+	if parameters == nil {
+		return 0, errors.New("unexpected nil parameters")
+	}
+	if len(pattern) == 0 {
+		return 0, errors.New("unexpected empty pattern")
+	}
+	if *parameters == nil {
+		*parameters = Parameters{}
+	}
+	segments := strings.Split(pattern, ".")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return 0, errors.Wrapf(err, "invalid pattern: '%s'", pattern)
+		}
+	}
+	return setGlob(*parameters, segments, value), nil
+}
+
+func setGlob(current map[string]interface{}, segments []string, value interface{}) int {
+	segment := segments[0]
+	last := len(segments) == 1
+
+	if !isPattern(segment) {
+		if last {
+			current[segment] = value
+			return 1
+		}
+		next, ok := asMap(current[segment])
+		if !ok {
+			return 0
+		}
+		return setGlob(next, segments[1:], value)
+	}
+
+	var count int
+	for _, key := range sortedKeys(current) {
+		// the pattern was validated already
+		if matched, _ := path.Match(segment, key); !matched {
+			continue
+		}
+		if last {
+			current[key] = value
+			count++
+			continue
+		}
+		if next, ok := asMap(current[key]); ok {
+			count += setGlob(next, segments[1:], value)
+		}
+	}
+	return count
+}
+
+func isPattern(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+// Transform returns a copy of the parameters with the function applied to every leaf (see Flatten)
+// matching the dotted pattern, each pattern segment is matched against a single key or slice index
+// using the path.Match syntax, e.g. '*.password' matches 'db.password' and 'users.*.password' matches
+// 'users[0].password'. The first error returned by the function aborts the transform,
+// the leaves are visited in the sorted key order
+func (parameters Parameters) Transform(pattern string, fn func(interface{}) (interface{}, error)) (Parameters, error) {
+	if len(pattern) == 0 {
+		return nil, errors.New("unexpected empty pattern")
+	}
+	segments := strings.Split(pattern, ".")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern: '%s'", pattern)
+		}
+	}
+
+	transformed := parameters.Clone()
+	_, err := transformValue(map[string]interface{}(transformed), "", nil, segments, fn)
+	if err != nil {
+		return nil, err
+	}
+	return transformed, nil
+}
+
+func transformValue(value interface{}, key string, keyPath, segments []string, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	if len(keyPath) > len(segments) {
+		return value, nil
+	}
+	if m, ok := asMap(value); ok && (len(m) > 0 || len(keyPath) == 0) {
+		for _, k := range sortedKeys(m) {
+			transformed, err := transformValue(m[k], joinKey(key, k), append(keyPath, k), segments, fn)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = transformed
+		}
+		return value, nil
+	}
+	if slice, ok := value.([]interface{}); ok && len(slice) > 0 {
+		for i, element := range slice {
+			index := strconv.Itoa(i)
+			transformed, err := transformValue(element, key+"["+index+"]", append(keyPath, index), segments, fn)
+			if err != nil {
+				return nil, err
+			}
+			slice[i] = transformed
+		}
+		return slice, nil
+	}
+
+	if !matchSegments(keyPath, segments) {
+		return value, nil
+	}
+	transformed, err := fn(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't transform key '%s'", key)
+	}
+	return transformed, nil
+}
+
+// matchSegments returns true if every key path segment matches the pattern segment
+func matchSegments(keyPath, segments []string) bool {
+	if len(keyPath) != len(segments) {
+		return false
+	}
+	for i, segment := range segments {
+		// the pattern was validated already
+		if matched, _ := path.Match(segment, keyPath[i]); !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGlob(t *testing.T) {
+	newServers := func() Parameters {
+		return Parameters{
+			"servers": Parameters{
+				"web": Parameters{"port": 80},
+				"db":  Parameters{"port": 5432},
+			},
+			"name": "render",
+		}
+	}
+
+	t.Run("multiple nested keys", func(t *testing.T) {
+		params := newServers()
+		count, err := SetGlob(&params, "servers.*.enabled", true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, Parameters{
+			"servers": Parameters{
+				"web": Parameters{"port": 80, "enabled": true},
+				"db":  Parameters{"port": 5432, "enabled": true},
+			},
+			"name": "render",
+		}, params)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		params := newServers()
+		count, err := SetGlob(&params, "clients.*.enabled", true)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, newServers(), params)
+	})
+
+	t.Run("single level wildcard", func(t *testing.T) {
+		params := newServers()
+		count, err := SetGlob(&params, "servers.w*", "replaced")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, Parameters{
+			"servers": Parameters{
+				"web": "replaced",
+				"db":  Parameters{"port": 5432},
+			},
+			"name": "render",
+		}, params)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		params := newServers()
+		_, err := SetGlob(&params, "servers.[.port", 1)
+		assert.EqualError(t, err, "invalid pattern: 'servers.[.port': syntax error in pattern")
+	})
+}
+
+func TestParameters_Transform(t *testing.T) {
+	params := Parameters{
+		"db":    Parameters{"host": "localhost", "password": "secret"},
+		"cache": Parameters{"password": "hidden"},
+		"users": []interface{}{Parameters{"name": "admin", "password": "admin"}},
+		"name":  "render",
+	}
+	redact := func(interface{}) (interface{}, error) { return "***", nil }
+
+	t.Run("matching leaves", func(t *testing.T) {
+		got, err := params.Transform("*.password", redact)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":    Parameters{"host": "localhost", "password": "***"},
+			"cache": Parameters{"password": "***"},
+			"users": []interface{}{Parameters{"name": "admin", "password": "admin"}},
+			"name":  "render",
+		}, got)
+		assert.Equal(t, "secret", params["db"].(Parameters)["password"])
+	})
+
+	t.Run("slice elements", func(t *testing.T) {
+		got, err := params.Transform("users.*.password", redact)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{Parameters{"name": "admin", "password": "***"}}, got["users"])
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		got, err := params.Transform("*.token", redact)
+		assert.NoError(t, err)
+		assert.Equal(t, params, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		got, err := params.Transform("*.password", func(value interface{}) (interface{}, error) {
+			return nil, errors.Errorf("can't encrypt '%v'", value)
+		})
+		assert.EqualError(t, err, "can't transform key 'cache.password': can't encrypt 'hidden'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := params.Transform("[.password", redact)
+		assert.EqualError(t, err, "invalid pattern: '[.password': syntax error in pattern")
+	})
+}
@@ -0,0 +1,173 @@
+package parameters
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is the normalized type of a parameter value
+type Kind int
+
+const (
+	// NullKind is the kind of the nil value
+	NullKind Kind = iota
+	// StringKind is the kind of the strings
+	StringKind
+	// IntKind is the kind of the signed and unsigned integers
+	IntKind
+	// FloatKind is the kind of the floating point numbers
+	FloatKind
+	// BoolKind is the kind of the booleans
+	BoolKind
+	// MapKind is the kind of the maps, Parameters and map[string]interface{}
+	MapKind
+	// SliceKind is the kind of the slices
+	SliceKind
+	// UnknownKind is the kind of any other value, also of the time.Duration,
+	// it isn't an IntKind even though it is an int64, so a duration isn't mistaken for a plain number
+	UnknownKind
+)
+
+var kindNames = map[Kind]string{
+	NullKind:    "null",
+	StringKind:  "string",
+	IntKind:     "int",
+	FloatKind:   "float",
+	BoolKind:    "bool",
+	MapKind:     "map",
+	SliceKind:   "slice",
+	UnknownKind: "unknown",
+}
+
+// String returns the lower case name of the kind, e.g. 'int'
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// kindOf returns the kind of the value
+func kindOf(value interface{}) Kind {
+	if value == nil {
+		return NullKind
+	}
+	if _, ok := asMap(value); ok {
+		return MapKind
+	}
+	switch value.(type) {
+	case string:
+		return StringKind
+	case bool:
+		return BoolKind
+	case []interface{}:
+		return SliceKind
+	case time.Duration:
+		return UnknownKind
+	}
+	if _, integer, ok := asNumber(value); ok {
+		if integer {
+			return IntKind
+		}
+		return FloatKind
+	}
+	return UnknownKind
+}
+
+// GetTyped returns the value for the dotted key like Get, its kind and whether it exists,
+// e.g. to switch on the kind without the reflection, a missing key is nil, NullKind and false
+func (parameters Parameters) GetTyped(key string) (interface{}, Kind, bool) {
+	value, ok := parameters.Get(key)
+	if !ok {
+		return nil, NullKind, false
+	}
+	return value, kindOf(value), true
+}
+
+// Coerce returns a copy of the parameters with the values of the dotted keys converted to the kinds,
+// e.g. the string '3' to the int64 3, the ints become int64 and the floats become float64,
+// the strings are parsed and any scalar can become a string, a missing key is skipped,
+// the null, the map and the slice kinds are only checked. A time.Duration is an UnknownKind (see Kind),
+// so it can only be converted to an int or a float (the nanoseconds). The unlisted keys are left as they are,
+// the first key (in the sorted order) that can't be converted is an error
+func (parameters Parameters) Coerce(types map[string]Kind) (Parameters, error) {
+	coerced := parameters.Clone()
+	keys := make([]string, 0, len(types))
+	for key := range types {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := coerced.Get(key)
+		if !ok {
+			continue
+		}
+		converted, err := coerceValue(value, types[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't coerce key '%s' to %s", key, types[key])
+		}
+		err = coerced.Set(key, converted)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't coerce key '%s' to %s", key, types[key])
+		}
+	}
+	return coerced, nil
+}
+
+func coerceValue(value interface{}, kind Kind) (interface{}, error) {
+	current := kindOf(value)
+	switch kind {
+	case StringKind:
+		switch current {
+		case StringKind, IntKind, FloatKind, BoolKind:
+			return fmt.Sprint(value), nil
+		}
+	case IntKind:
+		if s, ok := value.(string); ok {
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid int: '%s'", s)
+			}
+			return i, nil
+		}
+		if number, _, ok := asNumber(value); ok {
+			if number != math.Trunc(number) || number < math.MinInt64 || number >= math.MaxInt64 {
+				return nil, errors.Errorf("not an integral number: %v", value)
+			}
+			return int64(number), nil
+		}
+	case FloatKind:
+		if s, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid float: '%s'", s)
+			}
+			return f, nil
+		}
+		if number, _, ok := asNumber(value); ok {
+			return number, nil
+		}
+	case BoolKind:
+		if s, ok := value.(string); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, errors.Errorf("invalid bool: '%s'", s)
+			}
+			return b, nil
+		}
+		if current == BoolKind {
+			return value, nil
+		}
+	case NullKind, MapKind, SliceKind:
+		if current == kind {
+			return value, nil
+		}
+	}
+	return nil, errors.Errorf("unexpected %s value of type: '%T'", current, value)
+}
@@ -0,0 +1,115 @@
+package parameters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameters_GetTyped(t *testing.T) {
+	params := Parameters{
+		"app": Parameters{
+			"name":    "render",
+			"port":    8080,
+			"size":    uint8(3),
+			"ratio":   0.5,
+			"debug":   true,
+			"db":      map[string]interface{}{"host": "localhost"},
+			"hosts":   []interface{}{"a", Parameters{"zone": "eu"}},
+			"empty":   nil,
+			"timeout": time.Second,
+		},
+	}
+
+	tests := []struct {
+		key   string
+		value interface{}
+		kind  Kind
+	}{
+		{key: "app.name", value: "render", kind: StringKind},
+		{key: "app.port", value: 8080, kind: IntKind},
+		{key: "app.size", value: uint8(3), kind: IntKind},
+		{key: "app.ratio", value: 0.5, kind: FloatKind},
+		{key: "app.debug", value: true, kind: BoolKind},
+		{key: "app.db", value: map[string]interface{}{"host": "localhost"}, kind: MapKind},
+		{key: "app.hosts", value: []interface{}{"a", Parameters{"zone": "eu"}}, kind: SliceKind},
+		{key: "app.hosts.1.zone", value: "eu", kind: StringKind},
+		{key: "app.empty", value: nil, kind: NullKind},
+		{key: "app.timeout", value: time.Second, kind: UnknownKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, kind, ok := params.GetTyped(tt.key)
+			assert.True(t, ok)
+			assert.Equal(t, tt.value, value)
+			assert.Equal(t, tt.kind, kind, "kind: %s", kind)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		value, kind, ok := params.GetTyped("app.missing")
+		assert.False(t, ok)
+		assert.Nil(t, value)
+		assert.Equal(t, NullKind, kind)
+	})
+}
+
+func TestParameters_Coerce(t *testing.T) {
+	params := Parameters{
+		"replicas": "3",
+		"ratio":    1,
+		"debug":    "true",
+		"port":     8080,
+		"db":       Parameters{"timeout": "2.5", "name": "render"},
+	}
+
+	t.Run("coerced", func(t *testing.T) {
+		got, err := params.Coerce(map[string]Kind{
+			"replicas":   IntKind,
+			"ratio":      FloatKind,
+			"debug":      BoolKind,
+			"port":       StringKind,
+			"db.timeout": FloatKind,
+			"db.missing": IntKind,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"replicas": int64(3),
+			"ratio":    1.0,
+			"debug":    true,
+			"port":     "8080",
+			"db":       Parameters{"timeout": 2.5, "name": "render"},
+		}, got)
+		assert.Equal(t, "3", params["replicas"])
+	})
+
+	t.Run("untouched unlisted keys", func(t *testing.T) {
+		got, err := params.Coerce(map[string]Kind{"replicas": IntKind})
+		assert.NoError(t, err)
+		assert.Equal(t, "true", got["debug"])
+		assert.Equal(t, 8080, got["port"])
+		assert.Equal(t, Parameters{"timeout": "2.5", "name": "render"}, got["db"])
+	})
+
+	t.Run("bad coercion", func(t *testing.T) {
+		got, err := params.Coerce(map[string]Kind{"db.name": IntKind})
+		assert.EqualError(t, err, "can't coerce key 'db.name' to int: invalid int: 'render'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("kind mismatch", func(t *testing.T) {
+		_, err := params.Coerce(map[string]Kind{"db": SliceKind})
+		assert.EqualError(t, err, "can't coerce key 'db' to slice: unexpected map value of type: 'parameters.Parameters'")
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		durations := Parameters{"timeout": 2 * time.Second}
+		got, err := durations.Coerce(map[string]Kind{"timeout": IntKind})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"timeout": int64(2000000000)}, got)
+
+		_, err = durations.Coerce(map[string]Kind{"timeout": StringKind})
+		assert.EqualError(t, err, "can't coerce key 'timeout' to string: unexpected unknown value of type: 'time.Duration'")
+	})
+}
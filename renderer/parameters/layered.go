@@ -0,0 +1,41 @@
+package parameters
+
+// LayeredParameters is an ordered list of parameter sources, e.g. the environment, a file and the defaults,
+// every key is resolved from the first layer that has it, without merging the layers
+type LayeredParameters []Parameters
+
+// NewLayered creates the layered parameters, the first layer has the highest priority
+func NewLayered(layers ...Parameters) LayeredParameters {
+	return LayeredParameters(layers)
+}
+
+// Get returns the value for the dotted key (see Parameters.Get) from the first layer that has it
+// and whether it exists, a map value is returned as it is in that layer, without the keys of the lower layers
+func (layers LayeredParameters) Get(key string) (interface{}, bool) {
+	source := layers.Source(key)
+	if source < 0 {
+		return nil, false
+	}
+	return layers[source].Get(key)
+}
+
+// Exists returns true if any of the layers has the dotted key
+func (layers LayeredParameters) Exists(key string) bool {
+	return layers.Source(key) >= 0
+}
+
+// Source returns the index of the first layer that has the dotted key, or -1 if none of them has it
+func (layers LayeredParameters) Source(key string) int {
+	for i, layer := range layers {
+		if layer.Exists(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Merged returns all the layers merged into a new parameters, e.g. for rendering,
+// the higher layers win and the nested maps are merged recursively, see MergeFirstWins
+func (layers LayeredParameters) Merged() (Parameters, error) {
+	return MergeFirstWins(layers...)
+}
@@ -0,0 +1,61 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayeredParameters(t *testing.T) {
+	layers := NewLayered(
+		Parameters{"db": Parameters{"host": "env.example.com"}},
+		Parameters{"db": Parameters{"host": "file.example.com", "port": 6432}},
+		Parameters{"db": Parameters{"host": "localhost", "port": 5432, "user": "admin"}},
+	)
+
+	tests := []struct {
+		name   string
+		key    string
+		value  interface{}
+		source int
+	}{
+		{name: "first layer", key: "db.host", value: "env.example.com", source: 0},
+		{name: "second layer", key: "db.port", value: 6432, source: 1},
+		{name: "last layer", key: "db.user", value: "admin", source: 2},
+		{name: "missing", key: "db.password", value: nil, source: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := layers.Get(tt.key)
+			assert.Equal(t, tt.value, value)
+			assert.Equal(t, tt.source >= 0, ok)
+			assert.Equal(t, tt.source >= 0, layers.Exists(tt.key))
+			assert.Equal(t, tt.source, layers.Source(tt.key))
+		})
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		merged, err := layers.Merged()
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{
+			"host": "env.example.com",
+			"port": 6432,
+			"user": "admin",
+		}}, merged)
+	})
+	t.Run("merged agrees with get on a kind conflict", func(t *testing.T) {
+		conflicting := NewLayered(
+			Parameters{"db": "postgres://env"},
+			Parameters{"db": Parameters{"host": "file.example.com"}},
+		)
+		merged, err := conflicting.Merged()
+		assert.NoError(t, err)
+
+		value, ok := conflicting.Get("db")
+		assert.True(t, ok)
+		assert.Equal(t, 0, conflicting.Source("db"))
+		assert.Equal(t, value, merged["db"])
+		assert.Equal(t, Parameters{"db": "postgres://env"}, merged)
+	})
+}
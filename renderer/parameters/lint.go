@@ -0,0 +1,110 @@
+package parameters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// lintPathKeyRegexp matches the keys named like a path, e.g. 'configPath', 'output_dir' or 'url'
+	lintPathKeyRegexp = regexp.MustCompile(`(?i)(path|dir|directory|file|url)$`)
+	// lintPlaceholderRegexp matches an unexpanded placeholder, e.g. '${HOME}' or '${db.host}'
+	lintPlaceholderRegexp = regexp.MustCompile(`\$\{[^}]*\}`)
+)
+
+// LintMaxSingleChildChain is the number of the nested maps with a single key in a row, that Lint reports
+const LintMaxSingleChildChain = 4
+
+// Warning is a suspicious value found by Lint, the path is a flattened key (see Flatten)
+type Warning struct {
+	Path    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Path, w.Message)
+}
+
+// Lint returns the warnings, sorted by the path, about the common configuration mistakes:
+// an empty string for a key named like a path (e.g. 'configPath' or 'output_dir'),
+// an unexpanded '${...}' placeholder, the keys of a map that differ only by case,
+// and a chain of LintMaxSingleChildChain or more nested maps with a single key
+func Lint(parameters Parameters) []Warning {
+	var warnings []Warning
+	lintMap(&warnings, "", parameters)
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Path < warnings[j].Path
+	})
+	return warnings
+}
+
+func lintMap(warnings *[]Warning, prefix string, current map[string]interface{}) {
+	keys := sortedKeys(current)
+
+	byLowerCase := map[string][]string{}
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		byLowerCase[lower] = append(byLowerCase[lower], key)
+	}
+	for _, key := range keys {
+		if similar := byLowerCase[strings.ToLower(key)]; len(similar) > 1 && similar[0] == key {
+			*warnings = append(*warnings, Warning{
+				Path:    joinKey(prefix, key),
+				Message: fmt.Sprintf("keys differ only by case: '%s'", strings.Join(similar, "', '")),
+			})
+		}
+	}
+
+	// only the first map of a chain is reported, the root is never a part of a chain
+	chainHead := prefix == "" || len(keys) != 1
+	for _, key := range keys {
+		path := joinKey(prefix, key)
+		value := current[key]
+		if s, ok := value.(string); ok && s == "" && lintPathKeyRegexp.MatchString(key) {
+			*warnings = append(*warnings, Warning{Path: path, Message: "empty path"})
+		}
+		if depth := singleChildChain(value); depth >= LintMaxSingleChildChain && chainHead {
+			*warnings = append(*warnings, Warning{
+				Path:    path,
+				Message: fmt.Sprintf("%d nested maps with a single key", depth),
+			})
+		}
+		lintValue(warnings, path, value)
+	}
+}
+
+func lintValue(warnings *[]Warning, path string, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		if placeholder := lintPlaceholderRegexp.FindString(value); placeholder != "" {
+			*warnings = append(*warnings, Warning{
+				Path:    path,
+				Message: fmt.Sprintf("unexpanded placeholder: '%s'", placeholder),
+			})
+		}
+	case []interface{}:
+		for i, element := range value {
+			lintValue(warnings, path+"["+strconv.Itoa(i)+"]", element)
+		}
+	default:
+		if nested, ok := asMap(value); ok {
+			lintMap(warnings, path, nested)
+		}
+	}
+}
+
+// singleChildChain returns the number of the nested maps with a single key in a row
+func singleChildChain(value interface{}) int {
+	depth := 0
+	for {
+		m, ok := asMap(value)
+		if !ok || len(sortedKeys(m)) != 1 {
+			return depth
+		}
+		depth++
+		value = m[sortedKeys(m)[0]]
+	}
+}
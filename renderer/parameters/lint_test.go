@@ -0,0 +1,40 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("suspicious tree", func(t *testing.T) {
+		params := Parameters{
+			"configPath": "",
+			"name":       "",
+			"db": Parameters{
+				"host":     "${DB_HOST}",
+				"Host":     "localhost",
+				"password": "s3cr3t",
+			},
+			"hosts": []interface{}{"a", "prefix-${db.host}"},
+			"a":     Parameters{"b": Parameters{"c": Parameters{"d": Parameters{"e": 1}}}},
+		}
+		assert.Equal(t, []Warning{
+			{Path: "a", Message: "4 nested maps with a single key"},
+			{Path: "configPath", Message: "empty path"},
+			{Path: "db.Host", Message: "keys differ only by case: 'Host', 'host'"},
+			{Path: "db.host", Message: "unexpanded placeholder: '${DB_HOST}'"},
+			{Path: "hosts[1]", Message: "unexpanded placeholder: '${db.host}'"},
+		}, Lint(params))
+	})
+
+	t.Run("clean tree", func(t *testing.T) {
+		params := Parameters{
+			"configPath": "/etc/render",
+			"db":         Parameters{"host": "localhost", "port": 5432},
+			"hosts":      []interface{}{"a", "b"},
+			"a":          Parameters{"b": Parameters{"c": 1}},
+		}
+		assert.Empty(t, Lint(params))
+	})
+}
@@ -0,0 +1,99 @@
+package parameters
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LiveEnvOption mutates the LiveEnvParameters configuration
+type LiveEnvOption func(*liveEnvConfig)
+
+type liveEnvConfig struct {
+	environ func() []string
+	ticker  func(interval time.Duration) (<-chan time.Time, func())
+}
+
+// WithEnvSource makes LiveEnvParameters read the variables ('NAME=value') from the source instead of os.Environ
+func WithEnvSource(environ func() []string) LiveEnvOption {
+	return func(c *liveEnvConfig) {
+		c.environ = environ
+	}
+}
+
+// WithTicker makes LiveEnvParameters wait for the ticks of the ticker instead of a time.Ticker,
+// the ticker returns the ticks channel for the interval and the function stopping it
+func WithTicker(ticker func(interval time.Duration) (<-chan time.Time, func())) LiveEnvOption {
+	return func(c *liveEnvConfig) {
+		c.ticker = ticker
+	}
+}
+
+// LiveEnv is the configuration from the environment variables, kept up to date by LiveEnvParameters
+type LiveEnv struct {
+	mutex      sync.RWMutex
+	parameters Parameters
+}
+
+// Snapshot returns a copy of the current configuration, it is safe to call from many goroutines
+func (live *LiveEnv) Snapshot() Parameters {
+	live.mutex.RLock()
+	defer live.mutex.RUnlock()
+	return live.parameters.Clone()
+}
+
+func (live *LiveEnv) update(parameters Parameters) {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+	live.parameters = parameters
+}
+
+// LiveEnvParameters creates a configuration from the environment variables with the prefix and zero or more
+// options e.g. WithEnvSource, the keys are the variable names without the prefix, e.g. 'APP_DB_HOST'
+// with the 'APP_' prefix is 'DB_HOST', and the values are strings. The variables are read again
+// at every interval, until the returned cancel function is called, see LiveEnv.Snapshot
+func LiveEnvParameters(prefix string, interval time.Duration, options ...LiveEnvOption) (*LiveEnv, func()) {
+	c := liveEnvConfig{
+		environ: os.Environ,
+		ticker: func(interval time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(interval)
+			return ticker.C, ticker.Stop
+		},
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	live := &LiveEnv{parameters: fromEnviron(c.environ(), prefix)}
+	ticks, stop := c.ticker(interval)
+	done := make(chan struct{})
+	go func() {
+		defer stop()
+		for {
+			select {
+			case <-ticks:
+				live.update(fromEnviron(c.environ(), prefix))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return live, func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+func fromEnviron(environ []string, prefix string) Parameters {
+	config := Parameters{}
+	for _, variable := range environ {
+		i := strings.Index(variable, "=")
+		if i < 0 || !strings.HasPrefix(variable[:i], prefix) || i == len(prefix) {
+			continue
+		}
+		config[variable[len(prefix):i]] = variable[i+1:]
+	}
+	return config
+}
@@ -0,0 +1,92 @@
+package parameters
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEnv is an environment source changed by the test
+type fakeEnv struct {
+	mutex    sync.Mutex
+	environ  []string
+	interval time.Duration
+	ticks    chan time.Time
+	stopped  chan struct{}
+}
+
+func newFakeEnv(environ ...string) *fakeEnv {
+	return &fakeEnv{environ: environ, ticks: make(chan time.Time), stopped: make(chan struct{})}
+}
+
+func (env *fakeEnv) set(environ ...string) {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+	env.environ = environ
+}
+
+func (env *fakeEnv) options() []LiveEnvOption {
+	return []LiveEnvOption{
+		WithEnvSource(func() []string {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			return append([]string{}, env.environ...)
+		}),
+		WithTicker(func(interval time.Duration) (<-chan time.Time, func()) {
+			env.interval = interval
+			return env.ticks, func() { close(env.stopped) }
+		}),
+	}
+}
+
+func TestLiveEnvParameters(t *testing.T) {
+	t.Run("reloads after the interval", func(t *testing.T) {
+		env := newFakeEnv("APP_HOST=localhost", "APP_PORT=5432", "OTHER=x", "APP_=empty")
+		live, cancel := LiveEnvParameters("APP_", time.Minute, env.options()...)
+		defer cancel()
+
+		assert.Equal(t, time.Minute, env.interval)
+		assert.Equal(t, Parameters{"HOST": "localhost", "PORT": "5432"}, live.Snapshot())
+
+		env.set("APP_HOST=remote", "APP_DEBUG=a=b")
+		assert.Equal(t, Parameters{"HOST": "localhost", "PORT": "5432"}, live.Snapshot(), "not before the tick")
+
+		env.ticks <- time.Now()
+		assert.Eventually(t, func() bool {
+			return live.Snapshot()["HOST"] == "remote"
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, Parameters{"HOST": "remote", "DEBUG": "a=b"}, live.Snapshot())
+	})
+
+	t.Run("cancel stops polling", func(t *testing.T) {
+		env := newFakeEnv("APP_HOST=localhost")
+		live, cancel := LiveEnvParameters("APP_", time.Minute, env.options()...)
+
+		cancel()
+		cancel()
+		select {
+		case <-env.stopped:
+		case <-time.After(time.Second):
+			t.Fatal("the ticker wasn't stopped")
+		}
+
+		env.set("APP_HOST=remote")
+		select {
+		case env.ticks <- time.Now():
+			t.Fatal("the tick was received after cancel")
+		case <-time.After(10 * time.Millisecond):
+		}
+		assert.Equal(t, Parameters{"HOST": "localhost"}, live.Snapshot())
+	})
+
+	t.Run("snapshot is a copy", func(t *testing.T) {
+		env := newFakeEnv("APP_HOST=localhost")
+		live, cancel := LiveEnvParameters("APP_", time.Minute, env.options()...)
+		defer cancel()
+
+		live.Snapshot()["HOST"] = "changed"
+		assert.Equal(t, Parameters{"HOST": "localhost"}, live.Snapshot())
+	})
+}
@@ -0,0 +1,796 @@
+package parameters
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxMergeConfigs is the maximum number of the configurations merged at once by Merge and its variants,
+// see MergeLimited for a different limit
+const DefaultMaxMergeConfigs = 1000
+
+// mergeConfig defines how the configurations are folded by mergeWith
+type mergeConfig struct {
+	firstWins bool
+	accept    func(path []string, incoming interface{}) bool
+	hook      func(path []string, old, new interface{})
+	resolve   func(path []string, existing, incoming interface{}) (interface{}, error)
+	strategy  *MergeStrategy
+	// strictTypes makes overriding a value with a value of another kind an error
+	strictTypes bool
+	// log records the operations, see MergeWithLog
+	log func(operation MergeOperation, path []string, old, new interface{})
+	// maxConfigs replaces the DefaultMaxMergeConfigs if positive, a negative value disables the limit
+	maxConfigs int
+}
+
+// SliceStrategy defines how two slices under the same key are merged
+type SliceStrategy int
+
+const (
+	// ReplaceSlices makes the incoming slice replace the existing one, the same as Merge
+	ReplaceSlices SliceStrategy = iota
+	// AppendSlices appends the incoming elements to the existing slice,
+	// the numbers are widened to float64 if the result mixes the integers and the floats
+	AppendSlices
+	// AppendSlicesUnique appends the incoming elements like AppendSlices, but a scalar element
+	// is kept only on its first occurrence (e.g. a union of tags), the maps and the slices are always appended
+	AppendSlicesUnique
+)
+
+// MergeStrategy defines how MergeWithStrategy merges the slices
+type MergeStrategy struct {
+	// Slices is the strategy used for the slices without a key field
+	Slices SliceStrategy
+	// Keys maps the dotted path of a slice (e.g. "services" or "services.ports",
+	// the indexes of the elements are not part of the path) to the field identifying its elements,
+	// the elements with the same identity are merged recursively, the other ones are appended
+	Keys map[string]string
+	// SlicePaths are the dotted paths of the slices (the indexes of the elements are not part of the path),
+	// if set, a map with only index keys (e.g. from the 'servers.0.host=x' variable) under one of them
+	// is merged as the slice elements, also if there is no slice yet, and under the other paths
+	// the index keys are always the map keys, see Merge for the default behaviour
+	SlicePaths []string
+}
+
+// MergeFirstWins creates a new parameters from one or more parameter sets, like Merge,
+// but the first (most specific) configuration that sets a key wins and the later ones only fill the gaps,
+// the nested maps are still merged recursively, also a key conflict (see Merge) keeps the first value
+func MergeFirstWins(configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{firstWins: true}, configs...)
+}
+
+// MergeIf creates a new parameters from one or more parameter sets, like Merge,
+// but a value is only set if the predicate accepts it, the predicate is called
+// with the path of keys and the incoming value for every leaf (a value that is not a map)
+// and for every value conflicting with the existing one (see Merge), e.g. to avoid overriding
+// the values with empty strings
+func MergeIf(predicate func(path []string, incoming interface{}) bool, configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{accept: predicate}, configs...)
+}
+
+// MergeWithHook creates a new parameters from one or more parameter sets, like Merge,
+// and calls the hook every time a leaf (a value that is not a map) is set or overridden,
+// with the path of keys, the previous value (nil for a new key) and the new value
+func MergeWithHook(hook func(path []string, old, new interface{}), configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{hook: hook}, configs...)
+}
+
+// MergeWithResolver creates a new parameters from one or more parameter sets, like Merge,
+// but instead of the default resolution of a key conflict (a map in one configuration and a scalar or a slice
+// in another, see Merge) it calls the resolver with the path of keys, the existing and the incoming value
+// and uses the returned value, an error returned by the resolver aborts the merge
+func MergeWithResolver(resolve func(path []string, existing, incoming interface{}) (interface{}, error), configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{resolve: resolve}, configs...)
+}
+
+// MergeWithStrategy creates a new parameters from one or more parameter sets, like Merge,
+// but the slices are merged according to the strategy, by their elements identity
+// if the slice path has a key field, or else replaced or appended
+func MergeWithStrategy(strategy MergeStrategy, configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{strategy: &strategy}, configs...)
+}
+
+// MergeStrictTypes creates a new parameters from one or more parameter sets, like Merge,
+// but overriding a value with a value of another kind (see Kind) is an error with the path and the kinds,
+// e.g. an int with a string, an int with a time.Duration or a map with a scalar,
+// the nil values can override and be overridden
+func MergeStrictTypes(configs ...Parameters) (Parameters, error) {
+	return mergeWith(mergeConfig{strictTypes: true}, configs...)
+}
+
+// MergeLimited creates a new parameters from one or more parameter sets, like Merge,
+// but with the maximum number of the configurations instead of the DefaultMaxMergeConfigs,
+// e.g. to guard the endpoints merging the overlays of the untrusted callers, zero or less disables the limit
+func MergeLimited(max int, configs ...Parameters) (Parameters, error) {
+	if max <= 0 {
+		max = -1
+	}
+	return mergeWith(mergeConfig{maxConfigs: max}, configs...)
+}
+
+// MergeOperation is the kind of a change made by a merge, see MergeWithLog
+type MergeOperation string
+
+const (
+	// MergeSet is a new leaf (a value that is not a map) set at a missing key
+	MergeSet MergeOperation = "set"
+	// MergeOverride is an existing value replaced by a leaf (a value that is not a map)
+	MergeOverride MergeOperation = "override"
+	// MergeDeepMerge is a map merged into an existing map, followed by the operations on its keys
+	MergeDeepMerge MergeOperation = "deep-merge"
+)
+
+// LogEntry is a single operation of a merge, see MergeWithLog
+type LogEntry struct {
+	// Operation is the kind of the change
+	Operation MergeOperation
+	// Path is the path of keys, the slice indexes are the path segments too
+	Path []string
+	// Config is the index of the configuration the operation comes from
+	Config int
+	// Old is the previous value, nil for MergeSet and MergeDeepMerge
+	Old interface{}
+	// New is the incoming value
+	New interface{}
+}
+
+// MergeWithLog creates a new parameters from one or more parameter sets, like Merge, and returns the log
+// of the operations in the merge order, e.g. to explain the final configuration to the operators
+func MergeWithLog(configs ...Parameters) (Parameters, []LogEntry, error) {
+	var log []LogEntry
+	var index int
+	c := mergeConfig{log: func(operation MergeOperation, path []string, old, new interface{}) {
+		log = append(log, LogEntry{Operation: operation, Path: path, Config: index, Old: old, New: new})
+	}}
+
+	err := c.checkLimit(len(configs))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "can't merge the configurations")
+	}
+	accumulator := make(Parameters)
+	for i, config := range configs {
+		index = i
+		err := c.mergeInto(accumulator, config, nil)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "can't merge the configuration %d", i)
+		}
+	}
+	return accumulator, log, nil
+}
+
+// MergeDetectUnused merges the overlays into the base like Merge, and returns the sorted flattened keys
+// (see Flatten) the overlays introduced, that don't exist in the base, e.g. a 'relicas' typo,
+// extending a slice of the base or replacing a scalar of the base with a map doesn't introduce a key
+func MergeDetectUnused(base Parameters, overlays ...Parameters) (Parameters, []string, error) {
+	result, err := Merge(append([]Parameters{base}, overlays...)...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "can't merge the overlays")
+	}
+
+	unused := map[string]bool{}
+	for _, overlay := range overlays {
+		for key := range overlay.Flatten() {
+			if isIntroduced(base, key) {
+				unused[key] = true
+			}
+		}
+	}
+	var keys []string
+	for key := range unused {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return result, keys, nil
+}
+
+// isIntroduced returns true if a map key on the flattened key path doesn't exist in the base
+func isIntroduced(base Parameters, key string) bool {
+	var current interface{} = base
+	for _, part := range strings.Split(key, ".") {
+		name := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			indexes = strings.Split(strings.Trim(part[i:], "[]"), "][")
+		}
+
+		m, ok := asMap(current)
+		if !ok {
+			return false
+		}
+		if current, ok = m[name]; !ok {
+			return true
+		}
+		for _, index := range indexes {
+			slice, ok := current.([]interface{})
+			if !ok {
+				return false
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i >= len(slice) {
+				return false
+			}
+			current = slice[i]
+		}
+	}
+	return false
+}
+
+// ConflictInfo is a key conflict found by Conflicts, the types are the Go types e.g. 'parameters.Parameters' or 'string'
+type ConflictInfo struct {
+	Path         string
+	ExistingType string
+	IncomingType string
+}
+
+// Conflicts returns every key conflict (a map in one configuration and a scalar or a slice in another),
+// resolved by Merge without an error (see Merge), in the merge order,
+// the incoming value is assumed to win for the rest of the configurations
+func Conflicts(configs ...Parameters) []ConflictInfo {
+	var conflicts []ConflictInfo
+	_, _ = mergeWith(mergeConfig{
+		resolve: func(path []string, existing, incoming interface{}) (interface{}, error) {
+			conflicts = append(conflicts, ConflictInfo{
+				Path:         strings.Join(path, "."),
+				ExistingType: reflect.TypeOf(existing).String(),
+				IncomingType: reflect.TypeOf(incoming).String(),
+			})
+			return incoming, nil
+		},
+	}, configs...)
+	return conflicts
+}
+
+// MinimalOverride returns the smallest overlay that turns the base into the full parameters with Merge,
+// only the keys of the full parameters that differ from the base are kept, the nested maps recursively
+// and the slices as a whole, a value changed from a map to a scalar or a slice (or back) is kept as a whole,
+// the keys missing in full can't be removed by Merge and are ignored
+func MinimalOverride(base, full Parameters) Parameters {
+	return Parameters(minimalOverride(base, full))
+}
+
+func minimalOverride(base, full map[string]interface{}) map[string]interface{} {
+	override := map[string]interface{}{}
+	for _, key := range sortedKeys(full) {
+		value := full[key]
+		existing, exists := base[key]
+		if !exists {
+			override[key] = deepCopy(value)
+			continue
+		}
+		existingMap, existingIsMap := asMap(existing)
+		valueMap, valueIsMap := asMap(value)
+		if existingIsMap && valueIsMap {
+			if nested := minimalOverride(existingMap, valueMap); len(nested) > 0 {
+				if _, ok := value.(Parameters); ok {
+					override[key] = Parameters(nested)
+				} else {
+					override[key] = nested
+				}
+			}
+			continue
+		}
+		if !reflect.DeepEqual(existing, value) {
+			override[key] = deepCopy(value)
+		}
+	}
+	return override
+}
+
+// Intersect returns the keys that all the configurations have with the same values, e.g. the shared baseline
+// of the environments, the nested maps are intersected recursively and dropped if nothing is left of them,
+// unless they are empty in all the configurations, the other values (also the slices) are compared as a whole
+func Intersect(configs ...Parameters) Parameters {
+	if len(configs) == 0 {
+		return Parameters{}
+	}
+	maps := make([]map[string]interface{}, len(configs))
+	for i, config := range configs {
+		maps[i] = config
+	}
+	return Parameters(intersect(maps))
+}
+
+func intersect(maps []map[string]interface{}) map[string]interface{} {
+	common := map[string]interface{}{}
+	for _, key := range sortedKeys(maps[0]) {
+		value := maps[0][key]
+		valueMap, valueIsMap := asMap(value)
+		nestedMaps := []map[string]interface{}{valueMap}
+		shared := true
+		for _, other := range maps[1:] {
+			otherValue, exists := other[key]
+			if !exists {
+				shared = false
+				break
+			}
+			otherMap, otherIsMap := asMap(otherValue)
+			if valueIsMap && otherIsMap {
+				nestedMaps = append(nestedMaps, otherMap)
+				continue
+			}
+			if valueIsMap || otherIsMap || !reflect.DeepEqual(value, otherValue) {
+				shared = false
+				break
+			}
+		}
+		if !shared {
+			continue
+		}
+		if !valueIsMap {
+			common[key] = deepCopy(value)
+			continue
+		}
+
+		nested := intersect(nestedMaps)
+		if len(nested) == 0 && !allEmpty(nestedMaps) {
+			continue
+		}
+		if _, ok := value.(Parameters); ok {
+			common[key] = Parameters(nested)
+		} else {
+			common[key] = nested
+		}
+	}
+	return common
+}
+
+func allEmpty(maps []map[string]interface{}) bool {
+	for _, m := range maps {
+		if len(sortedKeys(m)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeWith(c mergeConfig, configs ...Parameters) (Parameters, error) {
+	err := c.checkLimit(len(configs))
+	if err != nil {
+		return nil, err
+	}
+	var accumulator = make(Parameters)
+	for _, config := range configs {
+		err := c.mergeInto(accumulator, config, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accumulator, nil
+}
+
+// checkLimit returns an error if there are more configurations than the maximum, see MergeLimited
+func (c mergeConfig) checkLimit(configs int) error {
+	max := c.maxConfigs
+	if max == 0 {
+		max = DefaultMaxMergeConfigs
+	}
+	if max > 0 && configs > max {
+		return errors.Errorf("too many configurations to merge: %d, the maximum is %d", configs, max)
+	}
+	return nil
+}
+
+// mergeInto merges the src map into the dst map, the values taken from src are deep copied,
+// so the dst never shares the nested maps or slices with the src
+func (c mergeConfig) mergeInto(dst, src map[string]interface{}, path []string) error {
+	for _, key := range sortedKeys(src) {
+		incoming := src[key]
+		keyPath := append(path[:len(path):len(path)], key)
+
+		existing, exists := dst[key]
+		if incomingMap, ok := asMap(incoming); ok {
+			if indexes, ok := sliceIndexes(incomingMap); ok && c.isIndexedSlice(keyPath, existing) {
+				existingSlice, ok := existing.([]interface{})
+				if !ok {
+					existingSlice = []interface{}{}
+				}
+				merged, err := c.mergeIndexed(keyPath, existingSlice, incomingMap, indexes)
+				if err != nil {
+					return err
+				}
+				dst[key] = merged
+				continue
+			}
+			if !exists {
+				existing = emptyLike(incoming)
+				dst[key] = existing
+			}
+			if existingMap, ok := asMap(existing); ok {
+				if exists && c.log != nil {
+					c.log(MergeDeepMerge, keyPath, nil, incoming)
+				}
+				err := c.mergeInto(existingMap, incomingMap, keyPath)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if exists && c.strictTypes && existing != nil && incoming != nil && kindOf(existing) != kindOf(incoming) {
+			return errors.Errorf("type change: key '%s' has type: '%s' and can't be overridden with type: '%s'",
+				strings.Join(keyPath, "."), kindOf(existing), kindOf(incoming))
+		}
+
+		if existingSlice, ok := existing.([]interface{}); ok && c.strategy != nil {
+			if incomingSlice, ok := incoming.([]interface{}); ok {
+				merged, err := c.mergeSlices(keyPath, existingSlice, incomingSlice)
+				if err != nil {
+					return err
+				}
+				dst[key] = merged
+				if _, keyed := c.sliceKey(keyPath); !keyed {
+					c.changed(keyPath, exists, existing, merged)
+				}
+				continue
+			}
+		}
+
+		if exists && c.firstWins {
+			continue
+		}
+		if c.accept != nil && !c.accept(keyPath, incoming) {
+			continue
+		}
+		if exists && isConflict(existing, incoming) {
+			resolved, err := c.resolveConflict(keyPath, existing, incoming)
+			if err != nil {
+				return err
+			}
+			dst[key] = deepCopy(resolved)
+			c.changed(keyPath, exists, existing, resolved)
+			continue
+		}
+
+		dst[key] = deepCopy(incoming)
+		c.changed(keyPath, exists, existing, incoming)
+	}
+	return nil
+}
+
+// changed reports a leaf set or overridden by the merge to the hook and the log
+func (c mergeConfig) changed(path []string, exists bool, old, new interface{}) {
+	if c.hook != nil {
+		c.hook(path, old, new)
+	}
+	if c.log == nil {
+		return
+	}
+	if exists {
+		c.log(MergeOverride, path, old, new)
+	} else {
+		c.log(MergeSet, path, nil, new)
+	}
+}
+
+// mergeSlices merges the incoming slice elements into a copy of the existing slice,
+// according to the merge strategy for the slice path, the changes of a keyed slice
+// are reported by the element paths (e.g. 'services.0.replicas'), see sliceKey
+func (c mergeConfig) mergeSlices(path []string, existing, incoming []interface{}) ([]interface{}, error) {
+	field, keyed := c.sliceKey(path)
+	if !keyed && c.strategy.Slices == ReplaceSlices {
+		return deepCopy(incoming).([]interface{}), nil
+	}
+
+	merged := deepCopy(existing).([]interface{})
+	for _, element := range incoming {
+		if keyed {
+			if index := indexByKey(merged, field, element); index >= 0 {
+				elementPath := append(path[:len(path):len(path)], strconv.Itoa(index))
+				existingMap, _ := asMap(merged[index])
+				incomingMap, _ := asMap(element)
+				if c.log != nil {
+					c.log(MergeDeepMerge, elementPath, nil, element)
+				}
+				err := c.mergeInto(existingMap, incomingMap, elementPath)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			c.changed(append(path[:len(path):len(path)], strconv.Itoa(len(merged))), false, nil, element)
+		}
+		merged = append(merged, deepCopy(element))
+	}
+	if !keyed {
+		widenNumbers(merged)
+	}
+	if c.strategy.Slices == AppendSlicesUnique {
+		merged = uniqueScalars(merged)
+	}
+	return merged, nil
+}
+
+// uniqueScalars removes the repeated scalar elements, keeping the first occurrence,
+// the numbers are compared by value, e.g. 1 and 1.0 are the same element
+func uniqueScalars(slice []interface{}) []interface{} {
+	unique := make([]interface{}, 0, len(slice))
+	seen := map[interface{}]bool{}
+	for _, element := range slice {
+		_, isMap := asMap(element)
+		_, isSlice := element.([]interface{})
+		if isMap || isSlice {
+			unique = append(unique, element)
+			continue
+		}
+		id := element
+		if number, _, ok := asNumber(element); ok {
+			id = number
+		}
+		if id != nil && !reflect.TypeOf(id).Comparable() {
+			unique = append(unique, element)
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, element)
+	}
+	return unique
+}
+
+// widenNumbers converts all the elements to float64 if they are all numbers and any of them is a float,
+// so the appended slices mixing the integers and the floats have a single type, the integer only slices are kept
+func widenNumbers(slice []interface{}) {
+	hasFloat := false
+	for _, element := range slice {
+		_, integer, ok := asNumber(element)
+		if !ok {
+			return
+		}
+		hasFloat = hasFloat || !integer
+	}
+	if !hasFloat {
+		return
+	}
+	for i, element := range slice {
+		slice[i], _, _ = asNumber(element)
+	}
+}
+
+// indexByKey returns the index of the element, in the slice, with the same key field value
+// as the given element, or -1 if there is none or the given element has no key field
+func indexByKey(slice []interface{}, field string, element interface{}) int {
+	elementMap, ok := asMap(element)
+	if !ok {
+		return -1
+	}
+	id, ok := elementMap[field]
+	if !ok {
+		return -1
+	}
+	for i, candidate := range slice {
+		candidateMap, ok := asMap(candidate)
+		if !ok {
+			continue
+		}
+		if candidateID, ok := candidateMap[field]; ok && reflect.DeepEqual(candidateID, id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isIndexedSlice returns true if a map with the index keys at the path is merged as the slice elements,
+// by default if the existing value is a slice, with the slice paths if the path is one of them
+// and the existing value is a slice or nil
+func (c mergeConfig) isIndexedSlice(path []string, existing interface{}) bool {
+	_, isSlice := existing.([]interface{})
+	if c.strategy == nil || len(c.strategy.SlicePaths) == 0 {
+		return isSlice
+	}
+	if !isSlice && existing != nil {
+		return false
+	}
+	joined := strategyPath(path)
+	for _, slicePath := range c.strategy.SlicePaths {
+		if slicePath == joined {
+			return true
+		}
+	}
+	return false
+}
+
+// sliceKey returns the field identifying the elements of the slice at the path and true if it has one,
+// see MergeStrategy.Keys
+func (c mergeConfig) sliceKey(path []string) (string, bool) {
+	if c.strategy == nil {
+		return "", false
+	}
+	field, ok := c.strategy.Keys[strategyPath(path)]
+	return field, ok
+}
+
+// strategyPath returns the dotted path without the slice indexes, used by the MergeStrategy paths
+func strategyPath(path []string) string {
+	var segments []string
+	for _, segment := range path {
+		if _, err := strconv.Atoi(segment); err != nil {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// sliceIndexes returns the sorted keys of the map as indexes, if all of them are non-negative integers
+func sliceIndexes(m map[string]interface{}) ([]int, bool) {
+	var indexes []int
+	for _, key := range sortedKeys(m) {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return nil, false
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes, len(indexes) > 0
+}
+
+// mergeIndexed merges the map with the index keys (e.g. from the 'items.0=c' variable) into a copy of the slice,
+// an element is merged recursively if both are maps or replaced otherwise, the slice is extended
+// with nil elements if an index is beyond its length.
+// A map with the index keys is only treated this way if the existing value is a slice,
+// otherwise it is merged as any other map, so e.g. '{"404": "not found"}' stays a map
+func (c mergeConfig) mergeIndexed(path []string, existing []interface{}, incoming map[string]interface{}, indexes []int) ([]interface{}, error) {
+	merged := deepCopy(existing).([]interface{})
+	for _, index := range indexes {
+		for len(merged) <= index {
+			merged = append(merged, nil)
+		}
+		key := strconv.Itoa(index)
+		elementPath := append(path[:len(path):len(path)], key)
+
+		element := incoming[key]
+		elementMap, elementIsMap := asMap(element)
+		if elementIsMap && merged[index] == nil {
+			merged[index] = emptyLike(element)
+		}
+		existingMap, existingIsMap := asMap(merged[index])
+		if elementIsMap && existingIsMap {
+			if index < len(existing) && existing[index] != nil && c.log != nil {
+				c.log(MergeDeepMerge, elementPath, nil, element)
+			}
+			err := c.mergeInto(existingMap, elementMap, elementPath)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		old := merged[index]
+		merged[index] = deepCopy(element)
+		c.changed(elementPath, index < len(existing), old, element)
+	}
+	return merged, nil
+}
+
+// resolveConflict returns the value for a key conflict, the incoming value by default, see Merge
+func (c mergeConfig) resolveConflict(path []string, existing, incoming interface{}) (interface{}, error) {
+	if c.resolve == nil {
+		return incoming, nil
+	}
+	resolved, err := c.resolve(path, existing, incoming)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't resolve the key conflict for key '%s'", strings.Join(path, "."))
+	}
+	return resolved, nil
+}
+
+// isConflict returns true if only one of the values is a map, nil values never conflict
+func isConflict(existing, incoming interface{}) bool {
+	if existing == nil || incoming == nil {
+		return false
+	}
+	_, existingIsMap := asMap(existing)
+	_, incomingIsMap := asMap(incoming)
+	return existingIsMap != incomingIsMap
+}
+
+// emptyLike returns a new empty map of the same type as the given map
+func emptyLike(value interface{}) interface{} {
+	if _, ok := value.(Parameters); ok {
+		return Parameters{}
+	}
+	return map[string]interface{}{}
+}
+
+// asMap returns the value as a map if it is one of the supported map types
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case Parameters:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+// deepCopy copies the nested maps and slices recursively, preserving their types,
+// other values are returned as they are
+func deepCopy(value interface{}) interface{} {
+	switch value := value.(type) {
+	case Parameters:
+		return Parameters(deepCopyMap(value))
+	case map[string]interface{}:
+		return deepCopyMap(value)
+	case []interface{}:
+		if value == nil {
+			return value
+		}
+		copied := make([]interface{}, len(value))
+		for i, element := range value {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	default:
+		return deepCopyReflect(value)
+	}
+}
+
+// deepCopyReflect copies the other slices and maps, e.g. []string or map[string]string,
+// so they aren't shared either, any other value is returned as it is
+func deepCopyReflect(value interface{}) interface{} {
+	original := reflect.ValueOf(value)
+	switch original.Kind() {
+	case reflect.Slice:
+		if original.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(original.Type(), original.Len(), original.Len())
+		for i := 0; i < original.Len(); i++ {
+			copied.Index(i).Set(deepCopyElement(original.Index(i)))
+		}
+		return copied.Interface()
+	case reflect.Map:
+		if original.IsNil() {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(original.Type(), original.Len())
+		iterator := original.MapRange()
+		for iterator.Next() {
+			copied.SetMapIndex(iterator.Key(), deepCopyElement(iterator.Value()))
+		}
+		return copied.Interface()
+	default:
+		return value
+	}
+}
+
+func deepCopyElement(element reflect.Value) reflect.Value {
+	if element.Kind() == reflect.Interface && element.IsNil() {
+		return element
+	}
+	copied := reflect.ValueOf(deepCopy(element.Interface()))
+	if !copied.IsValid() || !copied.Type().AssignableTo(element.Type()) {
+		return element
+	}
+	return copied
+}
+
+func deepCopyMap(value map[string]interface{}) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(value))
+	for k, v := range value {
+		copied[k] = deepCopy(v)
+	}
+	return copied
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,814 @@
+package parameters
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFirstWins(t *testing.T) {
+	t.Run("flat key", func(t *testing.T) {
+		got, err := MergeFirstWins(
+			Parameters{"key": "specific"},
+			Parameters{"key": "default", "other": "filled"},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"key":   "specific",
+			"other": "filled",
+		}, got)
+	})
+
+	t.Run("nested key", func(t *testing.T) {
+		got, err := MergeFirstWins(
+			Parameters{"a": Parameters{"nested": Parameters{"key": "specific"}}},
+			Parameters{"a": Parameters{"nested": Parameters{"key": "default"}}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"a": Parameters{"nested": Parameters{"key": "specific"}},
+		}, got)
+	})
+
+	t.Run("maps deep merge", func(t *testing.T) {
+		first := Parameters{"a": Parameters{"key": "specific"}}
+		got, err := MergeFirstWins(
+			first,
+			Parameters{"a": Parameters{"key": "default", "other": "filled"}},
+			Parameters{"a": map[string]interface{}{"more": "filled"}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"a": Parameters{
+				"key":   "specific",
+				"other": "filled",
+				"more":  "filled",
+			},
+		}, got)
+		assert.Equal(t, Parameters{"a": Parameters{"key": "specific"}}, first, "inputs should not be mutated")
+	})
+
+	t.Run("different kinds", func(t *testing.T) {
+		got, err := MergeFirstWins(Parameters{"a": 1}, Parameters{"a": map[string]interface{}{"x": 1}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": 1}, got)
+
+		got, err = MergeFirstWins(Parameters{"a": Parameters{"x": 1}}, Parameters{"a": "s"}, Parameters{"a": []interface{}{1}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": Parameters{"x": 1}}, got)
+	})
+}
+
+func TestMergeIf(t *testing.T) {
+	nonEmpty := func(path []string, incoming interface{}) bool {
+		return incoming != ""
+	}
+
+	t.Run("empty string rejected", func(t *testing.T) {
+		got, err := MergeIf(nonEmpty,
+			Parameters{"db": Parameters{"host": "localhost"}},
+			Parameters{"db": Parameters{"host": ""}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost"}}, got)
+	})
+
+	t.Run("non-empty accepted", func(t *testing.T) {
+		got, err := MergeIf(nonEmpty,
+			Parameters{"db": Parameters{"host": "localhost"}},
+			Parameters{"db": Parameters{"host": "remote", "port": ""}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "remote"}}, got)
+	})
+
+	t.Run("path info", func(t *testing.T) {
+		var paths []string
+		_, err := MergeIf(func(path []string, incoming interface{}) bool {
+			paths = append(paths, strings.Join(path, ".")+"="+incoming.(string))
+			return true
+		},
+			Parameters{"a": "1"},
+			Parameters{"a": "2", "b": Parameters{"c": Parameters{"d": "3"}}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a=1", "a=2", "b.c.d=3"}, paths)
+	})
+	t.Run("rejected conflict", func(t *testing.T) {
+		notString := func(path []string, incoming interface{}) bool {
+			_, ok := incoming.(string)
+			return !ok
+		}
+		got, err := MergeIf(notString, Parameters{"a": Parameters{"x": 1}}, Parameters{"a": "s"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": Parameters{"x": 1}}, got)
+
+		got, err = MergeIf(nonEmpty, Parameters{"a": Parameters{"x": 1}}, Parameters{"a": ""})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": Parameters{"x": 1}}, got)
+	})
+}
+
+func TestMergeWithHook(t *testing.T) {
+	type call struct {
+		path     string
+		old, new interface{}
+	}
+	var calls []call
+	hook := func(path []string, old, new interface{}) {
+		calls = append(calls, call{path: strings.Join(path, "."), old: old, new: new})
+	}
+
+	got, err := MergeWithHook(hook,
+		Parameters{"db": Parameters{"host": "localhost"}},
+		Parameters{"db": Parameters{"host": "remote", "port": 5432}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, Parameters{"db": Parameters{"host": "remote", "port": 5432}}, got)
+	assert.Equal(t, []call{
+		{path: "db.host", old: nil, new: "localhost"},
+		{path: "db.host", old: "localhost", new: "remote"},
+		{path: "db.port", old: nil, new: 5432},
+	}, calls)
+}
+
+func TestMergeWithResolver(t *testing.T) {
+	base := Parameters{"db": Parameters{"host": "localhost"}}
+	overlay := Parameters{"db": "postgres://remote"}
+
+	t.Run("default conflict", func(t *testing.T) {
+		got, err := Merge(base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": "postgres://remote"}, got)
+	})
+
+	t.Run("picks the existing", func(t *testing.T) {
+		got, err := MergeWithResolver(func(path []string, existing, incoming interface{}) (interface{}, error) {
+			assert.Equal(t, []string{"db"}, path)
+			return existing, nil
+		}, base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost"}}, got)
+	})
+
+	t.Run("picks the incoming", func(t *testing.T) {
+		got, err := MergeWithResolver(func(path []string, existing, incoming interface{}) (interface{}, error) {
+			return incoming, nil
+		}, base, overlay, Parameters{"db": Parameters{"port": 5432}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"port": 5432}}, got)
+	})
+
+	t.Run("returns an error", func(t *testing.T) {
+		got, err := MergeWithResolver(func(path []string, existing, incoming interface{}) (interface{}, error) {
+			return nil, errors.New("not allowed")
+		}, base, overlay)
+		assert.EqualError(t, err, "can't resolve the key conflict for key 'db': not allowed")
+		assert.Nil(t, got)
+	})
+}
+
+func TestMerge_ReadOnlyBase(t *testing.T) {
+	newBase := func() Parameters {
+		return Parameters{
+			"db":      Parameters{"host": "localhost", "pool": map[string]interface{}{"size": 5}},
+			"servers": []interface{}{Parameters{"name": "web"}},
+			"tags":    []string{"a", "b"},
+			"labels":  map[string]string{"team": "core"},
+		}
+	}
+	base := newBase()
+	frozen := base.Freeze()
+
+	got, err := Merge(base, Parameters{"db": Parameters{"port": 5432}})
+	assert.NoError(t, err)
+	got["db"].(Parameters)["host"] = "changed"
+	got["db"].(Parameters)["pool"].(map[string]interface{})["size"] = 10
+	got["servers"].([]interface{})[0].(Parameters)["name"] = "changed"
+	got["tags"].([]string)[0] = "changed"
+	got["labels"].(map[string]string)["team"] = "changed"
+
+	assert.Equal(t, newBase(), base)
+	assert.Equal(t, newBase(), frozen.Clone())
+
+	again, err := Merge(base)
+	assert.NoError(t, err)
+	assert.Equal(t, newBase(), again)
+}
+
+func TestMergeLimited(t *testing.T) {
+	configs := []Parameters{{"a": 1}, {"b": 2}, {"c": 3}, {"d": 4}}
+
+	t.Run("at the limit", func(t *testing.T) {
+		got, err := MergeLimited(3, configs[:3]...)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": 1, "b": 2, "c": 3}, got)
+	})
+
+	t.Run("over the limit", func(t *testing.T) {
+		got, err := MergeLimited(3, configs...)
+		assert.EqualError(t, err, "too many configurations to merge: 4, the maximum is 3")
+		assert.Nil(t, got)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		got, err := MergeLimited(0, configs...)
+		assert.NoError(t, err)
+		assert.Len(t, got, 4)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		many := make([]Parameters, DefaultMaxMergeConfigs+1)
+		for i := range many {
+			many[i] = Parameters{"a": i}
+		}
+		got, err := Merge(many[:DefaultMaxMergeConfigs]...)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"a": DefaultMaxMergeConfigs - 1}, got)
+
+		got, err = MergeFirstWins(many...)
+		assert.EqualError(t, err, "too many configurations to merge: 1001, the maximum is 1000")
+		assert.Nil(t, got)
+	})
+}
+
+func TestMergeWithLog(t *testing.T) {
+	base := Parameters{"name": "app", "db": Parameters{"host": "localhost"}}
+	overlay := Parameters{"name": "web", "db": Parameters{"port": 5432}}
+
+	t.Run("operations", func(t *testing.T) {
+		got, log, err := MergeWithLog(base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"name": "web", "db": Parameters{"host": "localhost", "port": 5432}}, got)
+		assert.Equal(t, []LogEntry{
+			{Operation: MergeSet, Path: []string{"db", "host"}, Config: 0, New: "localhost"},
+			{Operation: MergeSet, Path: []string{"name"}, Config: 0, New: "app"},
+			{Operation: MergeDeepMerge, Path: []string{"db"}, Config: 1, New: Parameters{"port": 5432}},
+			{Operation: MergeSet, Path: []string{"db", "port"}, Config: 1, New: 5432},
+			{Operation: MergeOverride, Path: []string{"name"}, Config: 1, Old: "app", New: "web"},
+		}, log)
+	})
+
+	t.Run("key conflict", func(t *testing.T) {
+		got, log, err := MergeWithLog(base, Parameters{"db": "postgres://remote"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"name": "app", "db": "postgres://remote"}, got)
+		assert.Equal(t, LogEntry{
+			Operation: MergeOverride, Path: []string{"db"}, Config: 1, Old: Parameters{"host": "localhost"}, New: "postgres://remote",
+		}, log[len(log)-1])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		got, log, err := MergeWithLog(make([]Parameters, DefaultMaxMergeConfigs+1)...)
+		assert.EqualError(t, err, "can't merge the configurations: too many configurations to merge: 1001, the maximum is 1000")
+		assert.Nil(t, got)
+		assert.Nil(t, log)
+	})
+}
+
+func TestMergeStrictTypes(t *testing.T) {
+	base := Parameters{"db": Parameters{"host": "localhost", "port": 5432}}
+
+	t.Run("same type override", func(t *testing.T) {
+		got, err := MergeStrictTypes(base, Parameters{"db": Parameters{"port": int64(5433), "user": "admin"}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost", "port": int64(5433), "user": "admin"}}, got)
+	})
+
+	t.Run("int to string", func(t *testing.T) {
+		got, err := MergeStrictTypes(base, Parameters{"db": Parameters{"port": "5433"}})
+		assert.EqualError(t, err,
+			"type change: key 'db.port' has type: 'int' and can't be overridden with type: 'string'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("map to scalar", func(t *testing.T) {
+		got, err := MergeStrictTypes(base, Parameters{"db": "postgres://remote"})
+		assert.EqualError(t, err,
+			"type change: key 'db' has type: 'map' and can't be overridden with type: 'string'")
+		assert.Nil(t, got)
+	})
+
+	t.Run("nil values", func(t *testing.T) {
+		got, err := MergeStrictTypes(base, Parameters{"db": Parameters{"host": nil}}, Parameters{"db": Parameters{"host": "remote"}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"db": Parameters{"host": "remote", "port": 5432}}, got)
+	})
+
+	t.Run("duration over int", func(t *testing.T) {
+		got, err := MergeStrictTypes(base, Parameters{"db": Parameters{"port": 5 * time.Second}})
+		assert.EqualError(t, err,
+			"type change: key 'db.port' has type: 'int' and can't be overridden with type: 'unknown'")
+		assert.Nil(t, got)
+	})
+}
+
+func TestMergeWithStrategy(t *testing.T) {
+	base := Parameters{
+		"tags": []interface{}{"a"},
+		"services": []interface{}{
+			Parameters{"name": "api", "replicas": 1, "ports": []interface{}{
+				Parameters{"number": 80, "protocol": "TCP"},
+			}},
+			Parameters{"name": "worker", "replicas": 1},
+		},
+	}
+	overlay := Parameters{
+		"tags": []interface{}{"b"},
+		"services": []interface{}{
+			Parameters{"name": "api", "replicas": 3, "ports": []interface{}{
+				Parameters{"number": 80, "protocol": "UDP"},
+				Parameters{"number": 443, "protocol": "TCP"},
+			}},
+			Parameters{"name": "cron", "replicas": 1},
+		},
+	}
+	expectedServices := []interface{}{
+		Parameters{"name": "api", "replicas": 3, "ports": []interface{}{
+			Parameters{"number": 80, "protocol": "UDP"},
+			Parameters{"number": 443, "protocol": "TCP"},
+		}},
+		Parameters{"name": "worker", "replicas": 1},
+		Parameters{"name": "cron", "replicas": 1},
+	}
+	keys := map[string]string{
+		"services":       "name",
+		"services.ports": "number",
+	}
+
+	t.Run("keyed slices with replace", func(t *testing.T) {
+		got, err := MergeWithStrategy(MergeStrategy{Keys: keys}, base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"tags":     []interface{}{"b"},
+			"services": expectedServices,
+		}, got)
+	})
+
+	t.Run("keyed slices with append", func(t *testing.T) {
+		got, err := MergeWithStrategy(MergeStrategy{Slices: AppendSlices, Keys: keys}, base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"tags":     []interface{}{"a", "b"},
+			"services": expectedServices,
+		}, got)
+	})
+
+	t.Run("inputs not mutated", func(t *testing.T) {
+		_, err := MergeWithStrategy(MergeStrategy{Keys: keys}, base, overlay)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, base["services"].([]interface{})[0].(Parameters)["replicas"])
+		assert.Len(t, base["services"].([]interface{}), 2)
+	})
+	t.Run("keyed elements reported by index", func(t *testing.T) {
+		var log []string
+		c := mergeConfig{
+			strategy: &MergeStrategy{Keys: keys},
+			log: func(operation MergeOperation, path []string, old, new interface{}) {
+				log = append(log, fmt.Sprintf("%s %s", operation, strings.Join(path, ".")))
+			},
+		}
+		got, err := mergeWith(c, Parameters{"services": base["services"]}, Parameters{"services": overlay["services"]})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"services": expectedServices}, got)
+		assert.Equal(t, []string{
+			"set services",
+			"deep-merge services.0",
+			"override services.0.name",
+			"deep-merge services.0.ports.0",
+			"override services.0.ports.0.number",
+			"override services.0.ports.0.protocol",
+			"set services.0.ports.1",
+			"override services.0.replicas",
+			"set services.2",
+		}, log)
+	})
+}
+
+func TestParameters_Merge(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    Parameters
+		overlay Parameters
+	}{
+		{
+			name:    "overlay",
+			base:    Parameters{"db": Parameters{"host": "localhost", "port": 5432}},
+			overlay: Parameters{"db": Parameters{"host": "remote"}, "debug": true},
+		},
+		{
+			name:    "conflict",
+			base:    Parameters{"db": Parameters{"host": "localhost"}},
+			overlay: Parameters{"db": "remote"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, expectedErr := Merge(tt.base, tt.overlay)
+			got, err := tt.base.Merge(tt.overlay)
+			assert.Equal(t, expected, got)
+			if expectedErr != nil {
+				assert.EqualError(t, err, expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMinimalOverride(t *testing.T) {
+	base := Parameters{
+		"name": "render",
+		"db":   Parameters{"host": "localhost", "port": 5432},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	tests := []struct {
+		name     string
+		full     Parameters
+		expected Parameters
+	}{
+		{
+			name: "nested leaf",
+			full: Parameters{
+				"name": "render",
+				"db":   Parameters{"host": "remote", "port": 5432},
+				"tags": []interface{}{"a", "b"},
+			},
+			expected: Parameters{"db": Parameters{"host": "remote"}},
+		},
+		{
+			name:     "unchanged",
+			full:     base.Clone(),
+			expected: Parameters{},
+		},
+		{
+			name: "slice and new key",
+			full: Parameters{
+				"name":  "render",
+				"db":    Parameters{"host": "localhost", "port": 5432},
+				"tags":  []interface{}{"a", "c"},
+				"debug": true,
+			},
+			expected: Parameters{"tags": []interface{}{"a", "c"}, "debug": true},
+		},
+		{
+			name: "map to scalar",
+			full: Parameters{
+				"name": "render",
+				"db":   "postgres://remote",
+				"tags": []interface{}{"a", "b"},
+			},
+			expected: Parameters{"db": "postgres://remote"},
+		},
+		{
+			name: "scalar and slice to map",
+			full: Parameters{
+				"name": Parameters{"short": "render"},
+				"db":   Parameters{"host": "localhost", "port": 5432},
+				"tags": Parameters{"env": "prod"},
+			},
+			expected: Parameters{"name": Parameters{"short": "render"}, "tags": Parameters{"env": "prod"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override := MinimalOverride(base, tt.full)
+			assert.Equal(t, tt.expected, override)
+
+			merged, err := Merge(base, override)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.full, merged)
+		})
+	}
+}
+
+func TestMerge_IndexedOverride(t *testing.T) {
+	loaded := Parameters{
+		"items":   []interface{}{"a", "b"},
+		"servers": []interface{}{Parameters{"name": "web", "port": 80}},
+		"codes":   Parameters{"404": "not found"},
+	}
+
+	t.Run("replace element", func(t *testing.T) {
+		vars, err := FromVars([]string{"items.0=c", "servers.0.port=8080"})
+		assert.NoError(t, err)
+		got, err := Merge(loaded, vars)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"c", "b"}, got["items"])
+		assert.Equal(t, []interface{}{Parameters{"name": "web", "port": "8080"}}, got["servers"])
+		assert.Equal(t, []interface{}{"a", "b"}, loaded["items"], "inputs should not be mutated")
+	})
+
+	t.Run("extend beyond length", func(t *testing.T) {
+		vars, err := FromVars([]string{"items.3=d", "items.2=c"})
+		assert.NoError(t, err)
+		got, err := Merge(loaded, vars)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "b", "c", "d"}, got["items"])
+
+		got, err = Merge(loaded, Parameters{"items": Parameters{"4": "e"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "b", nil, nil, "e"}, got["items"])
+	})
+
+	t.Run("map stays a map", func(t *testing.T) {
+		got, err := Merge(loaded, Parameters{"codes": Parameters{"500": "error"}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"404": "not found", "500": "error"}, got["codes"])
+	})
+
+	t.Run("non-index keys conflict", func(t *testing.T) {
+		got, err := Merge(loaded, Parameters{"items": Parameters{"first": "c"}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"first": "c"}, got["items"])
+	})
+}
+
+func TestMergeWithStrategy_SlicePaths(t *testing.T) {
+	strategy := MergeStrategy{SlicePaths: []string{"servers", "servers.ports"}}
+	vars, err := FromVars([]string{"servers.0.host=web", "servers.1.host=db", "servers.1.ports.0=5432", "codes.404=missing"})
+	assert.NoError(t, err)
+
+	t.Run("marked path indexes", func(t *testing.T) {
+		got, err := MergeWithStrategy(strategy, Parameters{}, vars)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"servers": []interface{}{
+				Parameters{"host": "web"},
+				Parameters{"host": "db", "ports": []interface{}{"5432"}},
+			},
+			"codes": Parameters{"404": "missing"},
+		}, got)
+	})
+
+	t.Run("marked path merged into a slice", func(t *testing.T) {
+		base := Parameters{"servers": []interface{}{Parameters{"host": "localhost", "port": 80}}}
+		got, err := MergeWithStrategy(strategy, base, vars)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			Parameters{"host": "web", "port": 80},
+			Parameters{"host": "db", "ports": []interface{}{"5432"}},
+		}, got["servers"])
+	})
+
+	t.Run("unmarked path stays a map", func(t *testing.T) {
+		base := Parameters{"codes": []interface{}{"ok"}}
+		got, err := MergeWithStrategy(strategy, base, Parameters{"codes": Parameters{"0": "error"}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"0": "error"}, got["codes"])
+	})
+
+	t.Run("without slice paths", func(t *testing.T) {
+		got, err := MergeWithStrategy(MergeStrategy{}, Parameters{}, vars)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"0": Parameters{"host": "web"}, "1": Parameters{"host": "db", "ports": Parameters{"0": "5432"}}}, got["servers"])
+	})
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		configs  []Parameters
+		expected []ConflictInfo
+	}{
+		{
+			name: "single conflict",
+			configs: []Parameters{
+				{"db": Parameters{"host": "localhost"}},
+				{"db": "localhost"},
+			},
+			expected: []ConflictInfo{
+				{Path: "db", ExistingType: "parameters.Parameters", IncomingType: "string"},
+			},
+		},
+		{
+			name: "multiple subtrees",
+			configs: []Parameters{
+				{"a": Parameters{"b": Parameters{"c": 1}}, "x": Parameters{"y": []interface{}{1}}},
+				{"a": Parameters{"b": 2}, "x": Parameters{"y": Parameters{"z": 1}}},
+				{"a": Parameters{"b": Parameters{"c": 3}}},
+			},
+			expected: []ConflictInfo{
+				{Path: "a.b", ExistingType: "parameters.Parameters", IncomingType: "int"},
+				{Path: "x.y", ExistingType: "[]interface {}", IncomingType: "parameters.Parameters"},
+				{Path: "a.b", ExistingType: "int", IncomingType: "parameters.Parameters"},
+			},
+		},
+		{
+			name: "no conflicts",
+			configs: []Parameters{
+				{"db": Parameters{"host": "localhost"}, "tags": []interface{}{"a"}},
+				{"db": Parameters{"port": 5432}, "tags": []interface{}{"b"}},
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Conflicts(tt.configs...))
+		})
+	}
+}
+
+func TestMergeWithStrategy_AppendWidening(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     []interface{}
+		overlay  []interface{}
+		expected []interface{}
+	}{
+		{
+			name:     "int only",
+			base:     []interface{}{1, 2},
+			overlay:  []interface{}{int64(3)},
+			expected: []interface{}{1, 2, int64(3)},
+		},
+		{
+			name:     "mixed widened to float",
+			base:     []interface{}{1, 2},
+			overlay:  []interface{}{1.5},
+			expected: []interface{}{1.0, 2.0, 1.5},
+		},
+		{
+			name:     "empty append",
+			base:     []interface{}{1, 2},
+			overlay:  []interface{}{},
+			expected: []interface{}{1, 2},
+		},
+		{
+			name:     "not only numbers",
+			base:     []interface{}{1, "two"},
+			overlay:  []interface{}{1.5},
+			expected: []interface{}{1, "two", 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeWithStrategy(MergeStrategy{Slices: AppendSlices},
+				Parameters{"weights": tt.base},
+				Parameters{"weights": tt.overlay},
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got["weights"])
+		})
+	}
+}
+
+func TestMergeWithStrategy_AppendUnique(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     []interface{}
+		overlay  []interface{}
+		expected []interface{}
+	}{
+		{
+			name:     "overlapping scalars",
+			base:     []interface{}{"a", "b"},
+			overlay:  []interface{}{"b", "c", "a"},
+			expected: []interface{}{"a", "b", "c"},
+		},
+		{
+			name:     "disjoint scalars",
+			base:     []interface{}{"a", 1},
+			overlay:  []interface{}{"b", 2, nil},
+			expected: []interface{}{"a", 1, "b", 2, nil},
+		},
+		{
+			name:     "numbers by value",
+			base:     []interface{}{1, 2},
+			overlay:  []interface{}{2.0, 2.5},
+			expected: []interface{}{1.0, 2.0, 2.5},
+		},
+		{
+			name:     "maps appended",
+			base:     []interface{}{Parameters{"name": "api"}},
+			overlay:  []interface{}{Parameters{"name": "api"}, "api"},
+			expected: []interface{}{Parameters{"name": "api"}, Parameters{"name": "api"}, "api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeWithStrategy(MergeStrategy{Slices: AppendSlicesUnique},
+				Parameters{"tags": tt.base},
+				Parameters{"tags": tt.overlay},
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got["tags"])
+		})
+	}
+
+	t.Run("keyed maps merged", func(t *testing.T) {
+		got, err := MergeWithStrategy(MergeStrategy{Slices: AppendSlicesUnique, Keys: map[string]string{"services": "name"}},
+			Parameters{"services": []interface{}{Parameters{"name": "api", "replicas": 1}}},
+			Parameters{"services": []interface{}{Parameters{"name": "api", "replicas": 3}, Parameters{"name": "cron"}}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			Parameters{"name": "api", "replicas": 3},
+			Parameters{"name": "cron"},
+		}, got["services"])
+	})
+}
+
+func TestMergeDetectUnused(t *testing.T) {
+	base := Parameters{
+		"replicas": 1,
+		"db":       Parameters{"host": "localhost"},
+		"tags":     []interface{}{"a"},
+		"extra":    nil,
+	}
+
+	t.Run("existing keys", func(t *testing.T) {
+		got, unused, err := MergeDetectUnused(base,
+			Parameters{"replicas": 3, "db": Parameters{"host": "remote"}},
+			Parameters{"tags": []interface{}{"a", "b"}, "extra": Parameters{"key": "value"}},
+		)
+		assert.NoError(t, err)
+		assert.Empty(t, unused)
+		assert.Equal(t, 3, got["replicas"])
+	})
+
+	t.Run("novel keys", func(t *testing.T) {
+		got, unused, err := MergeDetectUnused(base,
+			Parameters{"relicas": 3},
+			Parameters{"db": Parameters{"hots": "remote", "options": Parameters{"ssl": true}}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"db.hots", "db.options.ssl", "relicas"}, unused)
+		assert.Equal(t, Parameters{
+			"replicas": 1,
+			"relicas":  3,
+			"db":       Parameters{"host": "localhost", "hots": "remote", "options": Parameters{"ssl": true}},
+			"tags":     []interface{}{"a"},
+			"extra":    nil,
+		}, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		got, unused, err := MergeDetectUnused(base, make([]Parameters, DefaultMaxMergeConfigs)...)
+		assert.EqualError(t, err, "can't merge the overlays: too many configurations to merge: 1001, the maximum is 1000")
+		assert.Nil(t, got)
+		assert.Nil(t, unused)
+	})
+}
+
+func TestIntersect(t *testing.T) {
+	dev := Parameters{
+		"name":   "render",
+		"db":     Parameters{"port": 5432, "host": "dev.example.com", "options": Parameters{"ssl": true}},
+		"tags":   []interface{}{"a"},
+		"labels": Parameters{},
+		"debug":  true,
+	}
+	prod := Parameters{
+		"name":   "render",
+		"db":     Parameters{"port": 5432, "host": "prod.example.com", "options": Parameters{"ssl": false}},
+		"tags":   []interface{}{"a"},
+		"labels": Parameters{},
+	}
+	staging := Parameters{
+		"name":   "render",
+		"db":     Parameters{"port": 5432, "host": "staging.example.com"},
+		"tags":   []interface{}{"a"},
+		"labels": Parameters{},
+		"debug":  true,
+	}
+
+	t.Run("two configs", func(t *testing.T) {
+		got := Intersect(dev, prod)
+		assert.Equal(t, Parameters{
+			"name":   "render",
+			"db":     Parameters{"port": 5432},
+			"tags":   []interface{}{"a"},
+			"labels": Parameters{},
+		}, got)
+	})
+
+	t.Run("missing from one config", func(t *testing.T) {
+		got := Intersect(dev, staging, prod)
+		assert.NotContains(t, got, "debug")
+		assert.Equal(t, Parameters{"port": 5432}, got["db"])
+
+		got = Intersect(dev, staging)
+		assert.Equal(t, true, got["debug"])
+	})
+
+	t.Run("differing types", func(t *testing.T) {
+		got := Intersect(Parameters{"db": Parameters{"port": 1}}, Parameters{"db": "postgres"}, Parameters{"db": Parameters{"port": 1}})
+		assert.Equal(t, Parameters{}, got)
+	})
+
+	t.Run("no configs", func(t *testing.T) {
+		assert.Equal(t, Parameters{}, Intersect())
+	})
+}
@@ -0,0 +1,202 @@
+package parameters
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SecretMask replaces the secret values in the String output, see Annotated
+const SecretMask = "*****"
+
+// Annotations are the named notes attached to a key, e.g. a 'description' or a 'source', see Metadata.Annotate
+type Annotations map[string]string
+
+func (a Annotations) clone() Annotations {
+	c := make(Annotations, len(a))
+	for k, v := range a {
+		c[k] = v
+	}
+	return c
+}
+
+// Metadata holds the information about the parameters that is not a part of the values,
+// e.g. the secret marks and the annotations, keyed by the dotted paths (see Get),
+// it is kept next to the parameters (see Annotated), so it is never visible to the templates and the serializers,
+// the nil Metadata is empty and read-only
+type Metadata struct {
+	secrets     map[string]bool
+	annotations map[string]Annotations
+}
+
+// NewMetadata creates an empty metadata
+func NewMetadata() *Metadata {
+	return &Metadata{
+		secrets:     map[string]bool{},
+		annotations: map[string]Annotations{},
+	}
+}
+
+// Clone returns a deep copy of the metadata
+func (m *Metadata) Clone() *Metadata {
+	c := NewMetadata()
+	c.Merge(m)
+	return c
+}
+
+// Merge adds the secret marks and the annotations of the other metadata,
+// the other annotations override the existing ones with the same key and name
+func (m *Metadata) Merge(other *Metadata) {
+	if other == nil {
+		return
+	}
+	for k, v := range other.secrets {
+		m.secrets[k] = v
+	}
+	for key, annotations := range other.annotations {
+		if _, ok := m.annotations[key]; !ok {
+			m.annotations[key] = Annotations{}
+		}
+		for name, value := range annotations {
+			m.annotations[key][name] = value
+		}
+	}
+}
+
+// MarkSecret marks the dotted key as a secret, its value is masked in the String output of Annotated
+func (m *Metadata) MarkSecret(key string) {
+	m.secrets[key] = true
+}
+
+// IsSecret returns true if the dotted key was marked as a secret
+func (m *Metadata) IsSecret(key string) bool {
+	return m != nil && m.secrets[key]
+}
+
+// Secrets returns the sorted dotted keys marked as secrets
+func (m *Metadata) Secrets() []string {
+	if m == nil {
+		return nil
+	}
+	var secrets []string
+	for key := range m.secrets {
+		secrets = append(secrets, key)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// Annotate attaches the named annotation to the dotted key
+func (m *Metadata) Annotate(key, name, value string) {
+	if _, ok := m.annotations[key]; !ok {
+		m.annotations[key] = Annotations{}
+	}
+	m.annotations[key][name] = value
+}
+
+// Annotation returns the named annotation of the dotted key and true if it exists
+func (m *Metadata) Annotation(key, name string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	value, ok := m.annotations[key][name]
+	return value, ok
+}
+
+// Annotations returns a copy of all the annotations of the dotted key, or nil if there are none
+func (m *Metadata) Annotations(key string) Annotations {
+	if m == nil || m.annotations[key] == nil {
+		return nil
+	}
+	return m.annotations[key].clone()
+}
+
+// Mask returns a copy of the parameters with the existing secret values replaced with SecretMask,
+// a numeric key segment indexes a slice (e.g. 'users.0.token')
+func (m *Metadata) Mask(parameters Parameters) Parameters {
+	masked := parameters.Clone()
+	for _, key := range m.Secrets() {
+		maskKey(masked, strings.Split(key, "."))
+	}
+	return masked
+}
+
+// maskKey replaces the value of the key segments with SecretMask in place, a missing key is skipped
+func maskKey(value interface{}, segments []string) {
+	lastIndex := len(segments) - 1
+	for i, segment := range segments {
+		if m, ok := asMap(value); ok {
+			if _, exists := m[segment]; !exists {
+				return
+			}
+			if i == lastIndex {
+				m[segment] = SecretMask
+				return
+			}
+			value = m[segment]
+			continue
+		}
+		slice, ok := value.([]interface{})
+		index, err := strconv.Atoi(segment)
+		if !ok || err != nil || index < 0 || index >= len(slice) {
+			return
+		}
+		if i == lastIndex {
+			slice[index] = SecretMask
+			return
+		}
+		value = slice[index]
+	}
+}
+
+// Annotated is the parameters with their metadata kept next to the values,
+// e.g. returned by MergeWithSecrets and FromYAMLAnnotated
+type Annotated struct {
+	Parameters Parameters
+	Metadata   *Metadata
+}
+
+// NewAnnotated creates the annotated parameters with an empty metadata
+func NewAnnotated(parameters Parameters) Annotated {
+	return Annotated{
+		Parameters: parameters,
+		Metadata:   NewMetadata(),
+	}
+}
+
+// Clone returns a deep copy of the parameters and the metadata
+func (a Annotated) Clone() Annotated {
+	return Annotated{
+		Parameters: a.Parameters.Clone(),
+		Metadata:   a.Metadata.Clone(),
+	}
+}
+
+// String returns the parameters formatted like a map, the secret values are masked, see Metadata.MarkSecret
+func (a Annotated) String() string {
+	return fmt.Sprint(map[string]interface{}(a.Metadata.Mask(a.Parameters)))
+}
+
+// MergeWithSecrets loads the secrets file (see FromFile) and merges it last into the base,
+// every key set by the secrets file is marked as a secret in the metadata (see Metadata.MarkSecret),
+// so the values are usable for rendering, but are masked in the String output
+func MergeWithSecrets(base Parameters, secretsPath string) (Annotated, error) {
+	secrets, err := FromFile(secretsPath)
+	if err != nil {
+		return Annotated{}, errors.Wrap(err, "can't load the secrets")
+	}
+	merged, err := Merge(base, secrets)
+	if err != nil {
+		return Annotated{}, errors.Wrapf(err, "can't merge the secrets file '%s'", secretsPath)
+	}
+	result := NewAnnotated(merged)
+	secrets.Walk(func(path []string, _ interface{}) {
+		result.Metadata.MarkSecret(strings.Join(path, "."))
+	})
+	logrus.Debugf("Parameters with secrets: %v", result)
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package parameters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeWithSecrets(t *testing.T) {
+	base := Parameters{
+		"db": Parameters{
+			"host":     "localhost",
+			"password": "default",
+		},
+	}
+
+	got, err := MergeWithSecrets(base, "testdata/secrets.yaml")
+	assert.NoError(t, err)
+
+	t.Run("merged", func(t *testing.T) {
+		password, _ := got.Parameters.Get("db.password")
+		assert.Equal(t, "s3cr3t", password)
+		token, _ := got.Parameters.Get("token")
+		assert.Equal(t, "t0k3n", token)
+		assert.Equal(t, []string{"db.password", "token"}, got.Metadata.Secrets())
+		assert.True(t, got.Metadata.IsSecret("token"))
+		assert.False(t, got.Metadata.IsSecret("db.host"))
+	})
+
+	t.Run("masked", func(t *testing.T) {
+		s := got.String()
+		assert.Equal(t, "map[db:map[host:localhost password:*****] token:*****]", s)
+		assert.Equal(t, s, fmt.Sprintf("%v", got))
+		assert.NotContains(t, s, "s3cr3t")
+		assert.NotContains(t, s, "t0k3n")
+	})
+
+	t.Run("marks are not values", func(t *testing.T) {
+		assert.Len(t, got.Parameters, 2)
+		var keys []string
+		for key := range got.Parameters {
+			keys = append(keys, key)
+		}
+		assert.ElementsMatch(t, []string{"db", "token"}, keys)
+
+		b, err := json.Marshal(got.Parameters)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"db":{"host":"localhost","password":"s3cr3t"},"token":"t0k3n"}`, string(b))
+		b, err = yaml.Marshal(got.Parameters)
+		assert.NoError(t, err)
+		assert.Equal(t, "db:\n    host: localhost\n    password: s3cr3t\ntoken: t0k3n\n", string(b))
+		b, err = ToYAML(got.Parameters)
+		assert.NoError(t, err)
+		assert.Equal(t, "db:\n  host: localhost\n  password: s3cr3t\ntoken: t0k3n\n", string(b))
+	})
+
+	t.Run("marks survive clone", func(t *testing.T) {
+		clone := got.Clone()
+		clone.Metadata.MarkSecret("db.host")
+		assert.Equal(t, []string{"db.host", "db.password", "token"}, clone.Metadata.Secrets())
+		assert.Equal(t, []string{"db.password", "token"}, got.Metadata.Secrets(), "the original should not be modified")
+	})
+}
+
+func TestMetadata_Annotate(t *testing.T) {
+	params := NewAnnotated(Parameters{"db": Parameters{"host": "localhost"}})
+	params.Metadata.Annotate("db.host", "description", "the database host")
+	params.Metadata.Annotate("db.host", "source", "base.yaml")
+
+	t.Run("by path", func(t *testing.T) {
+		description, ok := params.Metadata.Annotation("db.host", "description")
+		assert.True(t, ok)
+		assert.Equal(t, "the database host", description)
+
+		_, ok = params.Metadata.Annotation("db.port", "description")
+		assert.False(t, ok)
+		assert.Equal(t, Annotations{"description": "the database host", "source": "base.yaml"}, params.Metadata.Annotations("db.host"))
+		assert.Nil(t, params.Metadata.Annotations("db"))
+	})
+
+	t.Run("survive a clone", func(t *testing.T) {
+		clone := params.Clone()
+		clone.Metadata.Annotate("db.host", "source", "override.yaml")
+
+		source, _ := clone.Metadata.Annotation("db.host", "source")
+		assert.Equal(t, "override.yaml", source)
+		source, _ = params.Metadata.Annotation("db.host", "source")
+		assert.Equal(t, "base.yaml", source, "the original should not be modified")
+	})
+
+	t.Run("not a part of the values", func(t *testing.T) {
+		assert.Equal(t, Parameters{"db": Parameters{"host": "localhost"}}, params.Parameters)
+		assert.Len(t, params.Parameters, 1)
+		assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}, params.Parameters.Map())
+		yaml, err := ToYAML(params.Parameters)
+		assert.NoError(t, err)
+		assert.Equal(t, "db:\n  host: localhost\n", string(yaml))
+
+		var unused struct {
+			DB struct {
+				Host string `mapstructure:"host"`
+			} `mapstructure:"db"`
+		}
+		assert.NoError(t, params.Parameters.Decode(&unused, WithErrorUnused()))
+		assert.Equal(t, "localhost", unused.DB.Host)
+	})
+
+	t.Run("nil metadata", func(t *testing.T) {
+		var empty *Metadata
+		_, ok := empty.Annotation("db.host", "source")
+		assert.False(t, ok)
+		assert.Nil(t, empty.Annotations("db.host"))
+		assert.False(t, empty.IsSecret("db.host"))
+	})
+}
+
+func TestFromYAMLAnnotated_SecretTag(t *testing.T) {
+	document := `
+db:
+  host: localhost
+  password: !secret hunter2
+  port: !secret 5432
+users:
+  - name: admin
+    token: !secret "abc def"
+`
+	got, err := FromYAMLAnnotated(strings.NewReader(document))
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", got.Parameters["db"].(Parameters)["password"])
+	assert.Equal(t, 5432, got.Parameters["db"].(Parameters)["port"])
+	assert.Equal(t, []string{"db.password", "db.port", "users.0.token"}, got.Metadata.Secrets())
+	assert.True(t, got.Metadata.IsSecret("db.password"))
+	assert.False(t, got.Metadata.IsSecret("db.host"))
+	assert.NotContains(t, got.String(), "hunter2")
+
+	token, ok := got.Parameters.Get("users.0.token")
+	assert.True(t, ok)
+	assert.Equal(t, "abc def", token)
+
+	plain, err := FromYAML(strings.NewReader(document))
+	assert.NoError(t, err)
+	assert.Equal(t, got.Parameters, plain)
+}
+
+func TestFromYAMLAnnotated_SecretTagWithWhenGuards(t *testing.T) {
+	document := `
+db:
+  password:
+    value: !secret hunter2
+    when: env == prod
+  token:
+    value: !secret dev-token
+    when: env == dev
+users:
+  - value: {name: guest, token: !secret guest-token}
+    when: env == dev
+  - name: admin
+    token: !secret admin-token
+`
+	got, err := FromYAMLAnnotated(strings.NewReader(document), WithWhenGuards(Parameters{"env": "prod"}))
+	assert.NoError(t, err)
+	assert.Equal(t, Parameters{
+		"db":    Parameters{"password": "hunter2"},
+		"users": []interface{}{Parameters{"name": "admin", "token": "admin-token"}},
+	}, got.Parameters)
+	assert.Equal(t, []string{"db.password", "users.0.token"}, got.Metadata.Secrets())
+	assert.Equal(t, "map[db:map[password:*****] users:[map[name:admin token:*****]]]", got.String())
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/VirtusLab/go-extended/pkg/matcher"
 
 	"github.com/ghodss/yaml"
-	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -17,11 +16,17 @@ import (
 const (
 	// RootKey is an special configuration key key used by e.g. the Base and Root functions
 	RootKey = "root"
+	// TemplatesKey is an special configuration key used by the renderer to register inline named templates
+	TemplatesKey = "_templates"
 )
 
 var (
-	// VarArgRegexp defines the extra variable parameter format
-	VarArgRegexp = matcher.Must(`^(?P<name>\S+)=(?P<value>[\S ]*)$`)
+	// VarArgRegexp defines the extra variable parameter format, the key ends at the first equals sign,
+	// so the value can contain equals signs
+	VarArgRegexp = matcher.Must(`^(?P<name>[^=\s]+)=(?P<value>[\S ]*)$`)
+	// VarArgTrimRegexp defines the extra variable parameter format used with WithTrimSpace,
+	// it allows for whitespace around the key, the equals sign and the value
+	VarArgTrimRegexp = matcher.Must(`^\s*(?P<name>[^=]*?)\s*=\s*(?P<value>.*?)\s*$`)
 )
 
 // Parameters is a map used to render the templates with
@@ -33,17 +38,20 @@ func (parameters Parameters) Validate() error {
 	return nil
 }
 
-// Merge creates a new parameters from one or more parameter sets, to be used with other helper functions
+// Merge creates a new parameters from one or more parameter sets, to be used with other helper functions.
+// The later configurations override the earlier ones, also with the empty values.
+// The nested maps are merged recursively.
+// A key that is a map in one configuration and a scalar or a slice in another takes the later value,
+// see MergeWithResolver.
+// A map with only the index keys (e.g. from the 'items.0=c' variable) merged into a slice
+// overrides the elements by index and extends the slice if needed
 func Merge(parameters ...Parameters) (Parameters, error) {
-	var accumulator = make(Parameters)
-	for _, config := range parameters {
-		err := merge(&accumulator, config)
-		if err != nil {
-			return nil, err
-		}
-	}
+	return mergeWith(mergeConfig{}, parameters...)
+}
 
-	return accumulator, nil
+// Merge creates a new parameters with the other parameters merged on top, the same as Merge(parameters, other)
+func (parameters Parameters) Merge(other Parameters) (Parameters, error) {
+	return Merge(parameters, other)
 }
 
 // All creates a configuration from one or more configuration file paths
@@ -112,27 +120,44 @@ func FromFiles(configPaths []string) (Parameters, error) {
 }
 
 // FromVars creates a configuration from one or more extra variables (key=value), see also VarArgRegexp
-func FromVars(extraParams []string) (Parameters, error) {
+// and zero or more options e.g. WithTrimSpace. The values are strings, unless the key is annotated
+// with a scalar parser name (key:name=value), see RegisterScalarParser. The bracket indices create
+// the slices only with WithIndexes or WithAutoIndex, see also ToVars
+func FromVars(extraParams []string, options ...VarsOption) (Parameters, error) {
+	c := newVarsConfig(options...)
+
 	var config = &Parameters{}
+	tree := newNestedTree(*config)
 	for _, v := range extraParams {
-		groups, ok := VarArgRegexp.MatchGroups(v)
-		if !ok {
-			logrus.Errorf("Expected a valid extra parameter: '%s'", v)
-			return nil, errors.Errorf("invalid parameter: '%s'", v)
+		info, err := c.analyze(v)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.Join(info.PathSegments, ".")
+		var value interface{} = info.Value
+		if len(info.Scalar) > 0 {
+			value, err = parseScalar(info.Scalar, info.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid parameter: '%s'", v)
+			}
 		}
-		name := groups["name"]
-		value := strings.Trim(groups["value"], `"'`)
-		logrus.Debugf("Extra var: %s=%s", name, value)
-		isNested := strings.Contains(name, ".")
-		if isNested {
+		logrus.Debugf("Extra var: %s=%v", name, value)
+		isNested := !c.flat && strings.Contains(name, ".")
+		if !c.flat && c.indexes && strings.Contains(name, "[") {
+			logrus.Debugf("Extra var key is indexed: %s", name)
+			err = setIndexed(config, name, value, c.autoIndex)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid parameter: '%s'", v)
+			}
+			tree.reset()
+		} else if isNested {
 			logrus.Debugf("Extra var key is nested: %s", name)
-			var err error
-			config, err = appendNested(config, name, value)
+			err = tree.set(name, value)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			(*config)[name] = value
+			tree.assign(*config, name, name, value)
 		}
 	}
 
@@ -140,6 +165,38 @@ func FromVars(extraParams []string) (Parameters, error) {
 	return *config, nil
 }
 
+// FromVarsFlat creates a configuration from one or more variables like FromVars,
+// but the keys are never nested, e.g. 'a.b.c=x' sets the 'a.b.c' key
+func FromVarsFlat(vars []string, options ...VarsOption) (Parameters, error) {
+	flat := func(c *varsConfig) {
+		c.flat = true
+	}
+	return FromVars(vars, append(options, flat)...)
+}
+
+// FromKeyValues creates a configuration from the alternating keys and values,
+// the keys must be strings and the dotted keys are nested e.g. FromKeyValues("db.host", "localhost", "debug", true)
+func FromKeyValues(kv ...interface{}) (Parameters, error) {
+	if len(kv)%2 != 0 {
+		return nil, errors.Errorf("odd number of arguments: %d, expected key and value pairs", len(kv))
+	}
+
+	var config = &Parameters{}
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			return nil, errors.Errorf("invalid key at position %d: '%v', it has type: '%s', expected a string",
+				i, kv[i], reflect.TypeOf(kv[i]))
+		}
+		var err error
+		config, err = appendNested(config, key, kv[i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return *config, nil
+}
+
 func appendNested(parameters *Parameters, nestedKey string, nestedValue interface{}) (*Parameters, error) {
 	if parameters == nil {
 		return nil, errors.New("unexpected nil parameters")
@@ -176,9 +233,5 @@ func appendNested(parameters *Parameters, nestedKey string, nestedValue interfac
 }
 
 func merge(dst *Parameters, src Parameters) error {
-	err := mergo.Merge(dst, src, mergo.WithOverride)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	return nil
+	return mergeConfig{}.mergeInto(*dst, src, nil)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/imdario/mergo"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -146,6 +147,55 @@ func TestNew(t *testing.T) {
 	}
 }
 
+// TestMerge_MergoCompatible checks that Merge is the same as github.com/imdario/mergo with the override option,
+// except for a map replacing a non-empty scalar or slice, which mergo drops
+func TestMerge_MergoCompatible(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []Parameters
+		want    Parameters
+		// unlikeMergo is set if mergo drops the incoming map
+		unlikeMergo bool
+	}{
+		{name: "empty string overrides", configs: []Parameters{{"a": "x"}, {"a": ""}}, want: Parameters{"a": ""}},
+		{name: "zero overrides", configs: []Parameters{{"a": 1}, {"a": 0}}, want: Parameters{"a": 0}},
+		{name: "nil overrides", configs: []Parameters{{"a": "x"}, {"a": nil}}, want: Parameters{"a": nil}},
+		{name: "nested empty overrides", configs: []Parameters{{"a": Parameters{"b": "x", "c": 1}}, {"a": Parameters{"b": ""}}},
+			want: Parameters{"a": Parameters{"b": "", "c": 1}}},
+		{name: "scalar replaces map", configs: []Parameters{{"a": Parameters{"b": 1}}, {"a": "s"}}, want: Parameters{"a": "s"}},
+		{name: "slice replaces map", configs: []Parameters{{"a": Parameters{"b": 1}}, {"a": []interface{}{1}}},
+			want: Parameters{"a": []interface{}{1}}},
+		{name: "nested scalar replaces map", configs: []Parameters{{"a": Parameters{"b": Parameters{"c": 1}}}, {"a": Parameters{"b": "s"}}},
+			want: Parameters{"a": Parameters{"b": "s"}}},
+		{name: "map replaces scalar", configs: []Parameters{{"a": "s"}, {"a": Parameters{"b": 1}}},
+			want: Parameters{"a": Parameters{"b": 1}}, unlikeMergo: true},
+		{name: "map replaces slice", configs: []Parameters{{"a": []interface{}{1}}, {"a": Parameters{"b": 1}}},
+			want: Parameters{"a": Parameters{"b": 1}}, unlikeMergo: true},
+		{name: "map replaces empty string", configs: []Parameters{{"a": ""}, {"a": Parameters{"b": 1}}},
+			want: Parameters{"a": Parameters{"b": 1}}},
+		{name: "map replaces false", configs: []Parameters{{"a": false}, {"a": Parameters{"b": 1}}},
+			want: Parameters{"a": Parameters{"b": 1}}},
+		{name: "map replaces empty slice", configs: []Parameters{{"a": []interface{}{}}, {"a": Parameters{"b": 1}}},
+			want: Parameters{"a": Parameters{"b": 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.configs...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if tt.unlikeMergo {
+				return
+			}
+
+			legacy := Parameters{}
+			for _, config := range tt.configs {
+				assert.NoError(t, mergo.Merge(&legacy, config.Clone(), mergo.WithOverride))
+			}
+			assert.Equal(t, legacy, got, "expected the same result as mergo")
+		})
+	}
+}
+
 func TestWithVars(t *testing.T) {
 	type args struct {
 		extraParams []string
@@ -241,6 +291,87 @@ func TestWithVars(t *testing.T) {
 	})
 }
 
+func TestWithVars_TrimSpace(t *testing.T) {
+	logrus.SetLevel(logrus.DebugLevel)
+
+	t.Run("padded key and value", func(t *testing.T) {
+		got, err := FromVars([]string{" key = value ", "\tnested.key=  other\t"}, WithTrimSpace())
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"key": "value",
+			"nested": Parameters{
+				"key": "other",
+			},
+		}, got)
+	})
+
+	t.Run("quoted value keeps interior spaces", func(t *testing.T) {
+		got, err := FromVars([]string{` key = "  a value  " `, `other= '  b '`}, WithTrimSpace())
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"key":   "  a value  ",
+			"other": "  b ",
+		}, got)
+	})
+
+	t.Run("all whitespace key", func(t *testing.T) {
+		got, err := FromVars([]string{"   =value"}, WithTrimSpace())
+		assert.EqualError(t, err, "invalid parameter, empty key: '   =value'")
+		assert.Nil(t, got)
+	})
+}
+
+func TestFromVarsFlat(t *testing.T) {
+	logrus.SetLevel(logrus.DebugLevel)
+
+	t.Run("dotted keys stay flat", func(t *testing.T) {
+		got, err := FromVarsFlat([]string{"a.b.c=x", "a.b=y", "plain=z"})
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"a.b.c": "x",
+			"a.b":   "y",
+			"plain": "z",
+		}, got)
+	})
+
+	t.Run("value with dots and equals", func(t *testing.T) {
+		got, err := FromVarsFlat([]string{`host.name="db.example.com"`, "query=a.b=c"})
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"host.name": "db.example.com",
+			"query":     "a.b=c",
+		}, got)
+	})
+}
+
+func TestWithVars_NestingToken(t *testing.T) {
+	logrus.SetLevel(logrus.DebugLevel)
+
+	t.Run("double underscore", func(t *testing.T) {
+		got, err := FromVars([]string{"DB__HOST=localhost", "DB__PORT=5432"}, WithNestingToken("__"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"DB": Parameters{"HOST": "localhost", "PORT": "5432"},
+		}, got)
+	})
+
+	t.Run("mixed with dots", func(t *testing.T) {
+		got, err := FromVars([]string{"APP__DB.HOST=localhost", "APP.DB__USER=admin"}, WithNestingToken("__"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"APP": Parameters{"DB": Parameters{"HOST": "localhost", "USER": "admin"}},
+		}, got)
+	})
+
+	t.Run("underscores in value and single underscore in key", func(t *testing.T) {
+		got, err := FromVars([]string{"DB__USER_NAME=my__user_name"}, WithNestingToken("__"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, Parameters{
+			"DB": Parameters{"USER_NAME": "my__user_name"},
+		}, got)
+	})
+}
+
 func TestAppendNested(t *testing.T) {
 	type args struct {
 		key        string
@@ -347,3 +478,40 @@ func TestAppendNested(t *testing.T) {
 		t.Run(fmt.Sprintf("[%d] %s", i, tt.name), func(t *testing.T) { tt.f(tt) })
 	}
 }
+
+func TestFromKeyValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		kv      []interface{}
+		want    Parameters
+		wantErr string
+	}{
+		{
+			name: "nested",
+			kv:   []interface{}{"a.b", 1, "a.c", true, "c", "x"},
+			want: Parameters{"a": Parameters{"b": 1, "c": true}, "c": "x"},
+		},
+		{
+			name:    "odd number of arguments",
+			kv:      []interface{}{"a.b", 1, "c"},
+			wantErr: "odd number of arguments: 3, expected key and value pairs",
+		},
+		{
+			name:    "non-string key",
+			kv:      []interface{}{"a", 1, 2, "x"},
+			wantErr: "invalid key at position 2: '2', it has type: 'int', expected a string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromKeyValues(tt.kv...)
+			if len(tt.wantErr) > 0 {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package parameterstest provides the test helpers for the parameters
+package parameterstest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+)
+
+// AssertEqual fails the test if the parameters differ, the nested Parameters
+// and map[string]interface{} are equal, and the numbers of different types are equal if they have the same value,
+// the failure message lists every flattened key (see Parameters.Flatten) that differs
+func AssertEqual(t testing.TB, want, got parameters.Parameters) bool {
+	t.Helper()
+	differences := Diff(want, got)
+	if len(differences) == 0 {
+		return true
+	}
+	t.Errorf("parameters differ:\n\t%s", strings.Join(differences, "\n\t"))
+	return false
+}
+
+// Diff returns the sorted differences between the parameters, one per flattened key, e.g.
+// "db.port: want 5432, got 6432", "db.user: missing, want 'admin'" or "debug: unexpected, got true"
+func Diff(want, got parameters.Parameters) []string {
+	wantFlat := want.Flatten()
+	gotFlat := got.Flatten()
+
+	var differences []string
+	for key, wantValue := range wantFlat {
+		gotValue, ok := gotFlat[key]
+		if !ok {
+			differences = append(differences, fmt.Sprintf("%s: missing, want %s", key, format(wantValue)))
+			continue
+		}
+		if !equal(wantValue, gotValue) {
+			differences = append(differences, fmt.Sprintf("%s: want %s, got %s", key, format(wantValue), format(gotValue)))
+		}
+	}
+	for key, gotValue := range gotFlat {
+		if _, ok := wantFlat[key]; !ok {
+			differences = append(differences, fmt.Sprintf("%s: unexpected, got %s", key, format(gotValue)))
+		}
+	}
+	sort.Strings(differences)
+	return differences
+}
+
+func equal(want, got interface{}) bool {
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+	wantNumber, wantOk := number(want)
+	gotNumber, gotOk := number(got)
+	if wantOk && gotOk {
+		return wantNumber == gotNumber
+	}
+	// only the empty maps are the flattened leaves
+	return isMap(want) && isMap(got)
+}
+
+func number(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func isMap(value interface{}) bool {
+	switch value.(type) {
+	case parameters.Parameters, map[string]interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func format(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("%v", value)
+}
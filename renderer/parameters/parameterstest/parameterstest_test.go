@@ -0,0 +1,54 @@
+package parameterstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertEqual(t *testing.T) {
+	t.Run("matching", func(t *testing.T) {
+		r := &recorder{}
+		ok := AssertEqual(r,
+			parameters.Parameters{"db": parameters.Parameters{"port": 5432, "options": parameters.Parameters{}}},
+			parameters.Parameters{"db": map[string]interface{}{"port": int64(5432), "options": map[string]interface{}{}}},
+		)
+		assert.True(t, ok)
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("mismatching", func(t *testing.T) {
+		r := &recorder{}
+		ok := AssertEqual(r,
+			parameters.Parameters{
+				"db":   parameters.Parameters{"host": "localhost", "port": 5432, "user": "admin"},
+				"tags": []interface{}{"a", "b"},
+			},
+			parameters.Parameters{
+				"db":    parameters.Parameters{"host": "localhost", "port": 6432},
+				"tags":  []interface{}{"a", "c"},
+				"debug": true,
+			},
+		)
+		assert.False(t, ok)
+		assert.Equal(t, []string{`parameters differ:
+	db.port: want 5432, got 6432
+	db.user: missing, want 'admin'
+	debug: unexpected, got true
+	tags[1]: want 'b', got 'c'`}, r.errors)
+	})
+}
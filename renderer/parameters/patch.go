@@ -0,0 +1,287 @@
+package parameters
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// patchOperation is a single RFC 6902 JSON Patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from"`
+	Value interface{} `json:"value"`
+}
+
+// ApplyPatch applies the RFC 6902 JSON Patch document (add, remove, replace, move, copy and test operations)
+// and returns a new parameters, the paths are JSON pointers e.g. '/db/hosts/0',
+// the parameters are not modified, also if any of the operations fails
+func (parameters Parameters) ApplyPatch(patch []byte) (Parameters, error) {
+	var operations []patchOperation
+	err := json.Unmarshal(patch, &operations)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse JSON patch")
+	}
+
+	var document interface{} = map[string]interface{}(parameters.Clone())
+	for i, operation := range operations {
+		document, err = applyOperation(document, operation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "patch operation %d '%s' failed", i, operation.Op)
+		}
+	}
+
+	patchedMap, ok := asMap(document)
+	if !ok {
+		return nil, errors.Errorf("patched document must be a map, it has type: '%s'", reflect.TypeOf(document))
+	}
+	return FromMap(patchedMap), nil
+}
+
+// MergePatch applies the RFC 7386 JSON Merge Patch document and returns a new parameters,
+// the patch maps are merged recursively, a null deletes the key and any other value
+// (also a slice) replaces the existing one, the parameters are not modified
+func (parameters Parameters) MergePatch(patch []byte) (Parameters, error) {
+	var document interface{}
+	err := json.Unmarshal(patch, &document)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse JSON merge patch")
+	}
+	patchMap, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("JSON merge patch must be an object, it has type: '%s'", reflect.TypeOf(document))
+	}
+
+	patched := parameters.Clone()
+	if patched == nil {
+		patched = Parameters{}
+	}
+	mergePatch(patched, patchMap)
+	return patched, nil
+}
+
+func mergePatch(target, patch map[string]interface{}) {
+	for _, key := range sortedKeys(patch) {
+		value := patch[key]
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		patchMap, ok := value.(map[string]interface{})
+		if !ok {
+			target[key] = toValue(value)
+			continue
+		}
+		if _, ok := asMap(target[key]); !ok {
+			target[key] = Parameters{}
+		}
+		existing, _ := asMap(target[key])
+		mergePatch(existing, patchMap)
+	}
+}
+
+func applyOperation(document interface{}, operation patchOperation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add":
+		return patchAt(document, path, addValue(operation.Value))
+	case "remove":
+		return patchAt(document, path, removeValue)
+	case "replace":
+		return patchAt(document, path, replaceValue(operation.Value))
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := valueAt(document, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" {
+			document, err = patchAt(document, from, removeValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return patchAt(document, path, addValue(deepCopy(value)))
+	case "test":
+		value, err := valueAt(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, operation.Value) {
+			return nil, errors.Errorf("test failed for path '%s'", operation.Path)
+		}
+		return document, nil
+	default:
+		return nil, errors.Errorf("unsupported operation: '%s'", operation.Op)
+	}
+}
+
+// parsePointer splits the JSON pointer into the unescaped tokens, an empty pointer is the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("invalid path: '%s', must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// patchAt calls the operation with the parent of the value pointed by the path and the last token,
+// and returns the document with the parent replaced by the one returned by the operation
+func patchAt(document interface{}, path []string, operation func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 0 {
+		return operation(nil, "")
+	}
+	if len(path) == 1 {
+		return operation(document, path[0])
+	}
+
+	child, err := childOf(document, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := patchAt(child, path[1:], operation)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := asMap(document); ok {
+		m[path[0]] = updated
+		return m, nil
+	}
+	slice := document.([]interface{})
+	index, _ := strconv.Atoi(path[0])
+	slice[index] = updated
+	return slice, nil
+}
+
+func valueAt(document interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		document, err = childOf(document, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+func childOf(node interface{}, token string) (interface{}, error) {
+	if m, ok := asMap(node); ok {
+		value, ok := m[token]
+		if !ok {
+			return nil, errors.Errorf("key '%s' doesn't exist", token)
+		}
+		return value, nil
+	}
+	if slice, ok := node.([]interface{}); ok {
+		index, err := sliceIndex(slice, token, false)
+		if err != nil {
+			return nil, err
+		}
+		return slice[index], nil
+	}
+	return nil, errors.Errorf("can't get key '%s' of type: '%s'", token, reflect.TypeOf(node))
+}
+
+// sliceIndex parses the token as an index of the slice, allowing the index just after the end if insert is true
+func sliceIndex(slice []interface{}, token string, insert bool) (int, error) {
+	index, err := strconv.Atoi(token)
+	limit := len(slice)
+	if insert {
+		limit++
+	}
+	if err != nil || index < 0 || index >= limit {
+		return 0, errors.Errorf("invalid index: '%s', slice has length: %d", token, len(slice))
+	}
+	return index, nil
+}
+
+func addValue(value interface{}) func(parent interface{}, token string) (interface{}, error) {
+	return func(parent interface{}, token string) (interface{}, error) {
+		if parent == nil && token == "" {
+			return value, nil
+		}
+		if m, ok := asMap(parent); ok {
+			m[token] = value
+			return m, nil
+		}
+		if slice, ok := parent.([]interface{}); ok {
+			if token == "-" {
+				return append(slice, value), nil
+			}
+			index, err := sliceIndex(slice, token, true)
+			if err != nil {
+				return nil, err
+			}
+			slice = append(slice, nil)
+			copy(slice[index+1:], slice[index:])
+			slice[index] = value
+			return slice, nil
+		}
+		return nil, errors.Errorf("can't add key '%s' to type: '%s'", token, reflect.TypeOf(parent))
+	}
+}
+
+func removeValue(parent interface{}, token string) (interface{}, error) {
+	if parent == nil && token == "" {
+		return nil, errors.New("can't remove the whole document")
+	}
+	if _, err := childOf(parent, token); err != nil {
+		return nil, err
+	}
+	if m, ok := asMap(parent); ok {
+		delete(m, token)
+		return m, nil
+	}
+	slice := parent.([]interface{})
+	index, _ := strconv.Atoi(token)
+	return append(slice[:index], slice[index+1:]...), nil
+}
+
+func replaceValue(value interface{}) func(parent interface{}, token string) (interface{}, error) {
+	return func(parent interface{}, token string) (interface{}, error) {
+		if parent == nil && token == "" {
+			return value, nil
+		}
+		if _, err := childOf(parent, token); err != nil {
+			return nil, err
+		}
+		if m, ok := asMap(parent); ok {
+			m[token] = value
+			return m, nil
+		}
+		slice := parent.([]interface{})
+		index, _ := strconv.Atoi(token)
+		slice[index] = value
+		return slice, nil
+	}
+}
+
+// jsonEqual compares the values by their JSON representation, so e.g. int 1 and float64 1 are equal
+func jsonEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	var aValue, bValue interface{}
+	_ = json.Unmarshal(aJSON, &aValue)
+	_ = json.Unmarshal(bJSON, &bValue)
+	return reflect.DeepEqual(aValue, bValue)
+}
@@ -0,0 +1,155 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameters_ApplyPatch(t *testing.T) {
+	base := func() Parameters {
+		return Parameters{
+			"db": Parameters{
+				"host":  "localhost",
+				"port":  5432,
+				"hosts": []interface{}{"a", "b"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected Parameters
+		err      string
+	}{
+		{
+			name:  "add",
+			patch: `[{"op": "add", "path": "/db/user", "value": "admin"}, {"op": "add", "path": "/db/hosts/1", "value": "c"}]`,
+			expected: Parameters{"db": Parameters{
+				"host": "localhost", "port": 5432, "user": "admin", "hosts": []interface{}{"a", "c", "b"},
+			}},
+		},
+		{
+			name:  "remove",
+			patch: `[{"op": "remove", "path": "/db/host"}, {"op": "remove", "path": "/db/hosts/0"}]`,
+			expected: Parameters{"db": Parameters{
+				"port": 5432, "hosts": []interface{}{"b"},
+			}},
+		},
+		{
+			name:  "replace",
+			patch: `[{"op": "replace", "path": "/db/host", "value": {"name": "remote"}}]`,
+			expected: Parameters{"db": Parameters{
+				"host": Parameters{"name": "remote"}, "port": 5432, "hosts": []interface{}{"a", "b"},
+			}},
+		},
+		{
+			name:  "move and copy",
+			patch: `[{"op": "move", "from": "/db/host", "path": "/host"}, {"op": "copy", "from": "/db/port", "path": "/port"}]`,
+			expected: Parameters{"host": "localhost", "port": 5432, "db": Parameters{
+				"port": 5432, "hosts": []interface{}{"a", "b"},
+			}},
+		},
+		{
+			name:     "test passes",
+			patch:    `[{"op": "test", "path": "/db/port", "value": 5432}]`,
+			expected: base(),
+		},
+		{
+			name:  "test fails",
+			patch: `[{"op": "replace", "path": "/db/host", "value": "remote"}, {"op": "test", "path": "/db/port", "value": 3306}]`,
+			err:   "patch operation 1 'test' failed: test failed for path '/db/port'",
+		},
+		{
+			name:  "missing key",
+			patch: `[{"op": "replace", "path": "/db/user", "value": "admin"}]`,
+			err:   "patch operation 0 'replace' failed: key 'user' doesn't exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base()
+			got, err := params.ApplyPatch([]byte(tt.patch))
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+			assert.Equal(t, base(), params, "parameters should not be modified")
+		})
+	}
+}
+
+func TestParameters_MergePatch(t *testing.T) {
+	base := Parameters{
+		"name": "render",
+		"db": Parameters{
+			"host":    "localhost",
+			"port":    5432,
+			"options": Parameters{"ssl": true, "timeout": 30},
+		},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected Parameters
+		err      string
+	}{
+		{
+			name:  "object merge",
+			patch: `{"db": {"host": "remote", "user": "admin", "options": {"timeout": 60}}, "extra": {"a": 1, "b": null}}`,
+			expected: Parameters{
+				"name": "render",
+				"db": Parameters{
+					"host": "remote", "port": 5432, "user": "admin",
+					"options": Parameters{"ssl": true, "timeout": 60.0},
+				},
+				"tags":  []interface{}{"a", "b"},
+				"extra": Parameters{"a": 1.0},
+			},
+		},
+		{
+			name:  "null deletes a nested key",
+			patch: `{"db": {"options": {"ssl": null}, "port": null}}`,
+			expected: Parameters{
+				"name": "render",
+				"db":   Parameters{"host": "localhost", "options": Parameters{"timeout": 30}},
+				"tags": []interface{}{"a", "b"},
+			},
+		},
+		{
+			name:  "scalar and slice replacement",
+			patch: `{"name": "app", "tags": ["c"], "db": "postgres://localhost"}`,
+			expected: Parameters{
+				"name": "app",
+				"db":   "postgres://localhost",
+				"tags": []interface{}{"c"},
+			},
+		},
+		{
+			name:  "not an object",
+			patch: `["a"]`,
+			err:   "JSON merge patch must be an object, it has type: '[]interface {}'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := base.MergePatch([]byte(tt.patch))
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, 5432, base["db"].(Parameters)["port"], "the parameters should not be modified")
+		})
+	}
+}
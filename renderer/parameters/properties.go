@@ -0,0 +1,142 @@
+package parameters
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FromProperties creates a configuration from a Java .properties document,
+// the dotted keys are nested e.g. 'db.host=localhost', the values are strings,
+// the '#' and '!' comments, the '\' line continuations and the escaped characters are supported
+func FromProperties(r io.Reader) (Parameters, error) {
+	lines, err := propertiesLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := Parameters{}
+	for _, line := range lines {
+		key, value, err := propertiesKeyValue(line)
+		if err != nil {
+			return nil, err
+		}
+		err = parameters.Set(key, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't set the property '%s'", key)
+		}
+	}
+	return parameters, nil
+}
+
+// propertiesLines reads the logical lines, joining the continued lines
+// and skipping the blank and the comment lines
+func propertiesLines(r io.Reader) ([]string, error) {
+	var lines []string
+	var logical strings.Builder
+	continued := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if !continued && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		continued = endsWithContinuation(line)
+		if continued {
+			line = line[:len(line)-1]
+		}
+		logical.WriteString(line)
+		if !continued {
+			lines = append(lines, logical.String())
+			logical.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "can't read properties")
+	}
+	if logical.Len() > 0 {
+		lines = append(lines, logical.String())
+	}
+	return lines, nil
+}
+
+// endsWithContinuation returns true if the line ends with an odd number of backslashes
+func endsWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// propertiesKeyValue splits the logical line at the first unescaped '=', ':' or whitespace
+func propertiesKeyValue(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", errors.Errorf("invalid unicode escape in property: '%s'", s)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.Errorf("invalid unicode escape in property: '%s'", s)
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
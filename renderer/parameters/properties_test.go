@@ -0,0 +1,55 @@
+package parameters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromProperties(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		expected Parameters
+	}{
+		{
+			name:     "continuation line",
+			document: "message = Hello, \\\n    World\n",
+			expected: Parameters{"message": "Hello, World"},
+		},
+		{
+			name:     "comments",
+			document: "# a comment\n! another comment\n\nkey=value\n",
+			expected: Parameters{"key": "value"},
+		},
+		{
+			name:     "escaped equals in key",
+			document: "a\\=b=c\n",
+			expected: Parameters{"a=b": "c"},
+		},
+		{
+			name:     "separators",
+			document: "colon: value\nspace value\nescaped=tab\\there \\u0041\n",
+			expected: Parameters{"colon": "value", "space": "value", "escaped": "tab\there A"},
+		},
+		{
+			name:     "nesting from dotted keys",
+			document: "db.host=localhost\ndb.port=5432\n",
+			expected: Parameters{"db": Parameters{"host": "localhost", "port": "5432"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProperties(strings.NewReader(tt.document))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("key conflict", func(t *testing.T) {
+		_, err := FromProperties(strings.NewReader("db=local\ndb.host=localhost\n"))
+		assert.EqualError(t, err, "can't set the property 'db.host': key conflict: key 'db' already exists and is not a map, it has type: 'string'")
+	})
+}
@@ -0,0 +1,23 @@
+package parameters
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ToProtoStruct converts the parameters to a protobuf Struct (google.protobuf.Struct) e.g. for a gRPC call,
+// the numbers become doubles, the nil values become nulls,
+// the values without a Struct representation (e.g. time.Duration) are an error
+func (parameters Parameters) ToProtoStruct() (*structpb.Struct, error) {
+	s, err := structpb.NewStruct(parameters.Map())
+	if err != nil {
+		return nil, errors.Wrap(err, "can't convert to protobuf Struct")
+	}
+	return s, nil
+}
+
+// FromProtoStruct creates a configuration from a protobuf Struct (google.protobuf.Struct),
+// it is the inverse of ToProtoStruct, with all the numbers as float64
+func FromProtoStruct(s *structpb.Struct) Parameters {
+	return FromMap(s.AsMap())
+}
@@ -0,0 +1,58 @@
+package parameters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParameters_ToProtoStruct(t *testing.T) {
+	params := Parameters{
+		"name":  "app",
+		"debug": true,
+		"empty": nil,
+		"db": Parameters{
+			"port":  5432,
+			"ratio": 0.5,
+			"hosts": []interface{}{"a", int64(2), Parameters{"zone": "eu"}},
+		},
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		s, err := params.ToProtoStruct()
+		assert.NoError(t, err)
+
+		b, err := proto.Marshal(s)
+		assert.NoError(t, err)
+		var decoded structpb.Struct
+		assert.NoError(t, proto.Unmarshal(b, &decoded))
+
+		assert.Equal(t, Parameters{
+			"name":  "app",
+			"debug": true,
+			"empty": nil,
+			"db": Parameters{
+				"port":  float64(5432),
+				"ratio": 0.5,
+				"hosts": []interface{}{"a", float64(2), Parameters{"zone": "eu"}},
+			},
+		}, FromProtoStruct(&decoded))
+	})
+
+	t.Run("structpb values", func(t *testing.T) {
+		s, err := params.ToProtoStruct()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(5432), s.Fields["db"].GetStructValue().Fields["port"].GetNumberValue())
+		assert.Equal(t, structpb.NullValue_NULL_VALUE, s.Fields["empty"].GetNullValue())
+	})
+
+	t.Run("unsupported value", func(t *testing.T) {
+		_, err := Parameters{"timeout": time.Second}.ToProtoStruct()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "can't convert to protobuf Struct: ")
+		assert.Contains(t, err.Error(), "invalid type: time.Duration")
+	})
+}
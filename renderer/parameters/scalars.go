@@ -0,0 +1,167 @@
+package parameters
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScalarParser parses a string into a native value, it returns false if the string is not valid
+type ScalarParser func(string) (interface{}, bool)
+
+var (
+	scalarParsersMutex sync.RWMutex
+	scalarParsers      = map[string]ScalarParser{
+		"string":   func(s string) (interface{}, bool) { return s, true },
+		"bool":     parseBool,
+		"int":      parseInt,
+		"float":    parseFloat,
+		"duration": parseDuration,
+		"bytes":    parseByteSize,
+	}
+)
+
+// RegisterScalarParser registers (or replaces) a named scalar parser used by FromVars
+// with the 'key:name=value' syntax, e.g. 'timeout:duration=30s', the name must be the lower case letters,
+// any other colon is a part of the key (e.g. 'http://localhost:8080=up' sets the 'http://localhost:8080' key),
+// the built-in parsers are: string, bool, int, float, duration and bytes
+func RegisterScalarParser(name string, fn func(string) (interface{}, bool)) {
+	scalarParsersMutex.Lock()
+	defer scalarParsersMutex.Unlock()
+	scalarParsers[name] = fn
+}
+
+// isScalarName returns true if the name can annotate a key with a scalar parser, see RegisterScalarParser
+func isScalarName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, r := range name {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseScalar(name, value string) (interface{}, error) {
+	scalarParsersMutex.RLock()
+	parser, ok := scalarParsers[name]
+	scalarParsersMutex.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unregistered scalar parser: '%s'", name)
+	}
+	parsed, ok := parser(value)
+	if !ok {
+		return nil, errors.Errorf("can't parse '%s' as '%s'", value, name)
+	}
+	return parsed, nil
+}
+
+func parseBool(s string) (interface{}, bool) {
+	b, err := strconv.ParseBool(s)
+	return b, err == nil
+}
+
+func parseInt(s string) (interface{}, bool) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	return i, err == nil
+}
+
+func parseFloat(s string) (interface{}, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func parseDuration(s string) (interface{}, bool) {
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+var byteSizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([KMGTPE]i?)?B?$`)
+
+var byteSizeMultipliers = map[string]float64{
+	"":   1,
+	"K":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseByteSize parses a byte size with an optional decimal (e.g. 5MB)
+// or binary (e.g. 5Mi, 5MiB) unit into the number of bytes (int64)
+func parseByteSize(s string) (interface{}, bool) {
+	match := byteSizeRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return nil, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil, false
+	}
+	size := number * byteSizeMultipliers[match[2]]
+	if size > math.MaxInt64 {
+		return nil, false
+	}
+	return int64(size), true
+}
+
+// durationRegexp matches the durations with the units, like the ones parsed by time.ParseDuration
+var durationRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`)
+
+// unitByteSizeRegexp matches the byte sizes with a binary unit or a decimal unit (with the 'B' suffix)
+var unitByteSizeRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([KMGTPE]iB?|[KMGTPE]B)$`)
+
+// CoerceUnitScalars returns a copy of the parameters with the strings exactly matching a duration
+// (e.g. '30s' or '1h30m') converted to time.Duration and the strings exactly matching a byte size
+// with an explicit unit (e.g. '5Mi', '5MiB' or '5MB') converted to the number of bytes (int64),
+// also in the nested maps and slices, the other strings (e.g. '5M', '30' or ' 30s') are left as they are
+func CoerceUnitScalars(parameters Parameters) Parameters {
+	coerced := parameters.Clone()
+	coerceUnitScalars(coerced)
+	return coerced
+}
+
+func coerceUnitScalars(value interface{}) interface{} {
+	if m, ok := asMap(value); ok {
+		for _, k := range sortedKeys(m) {
+			m[k] = coerceUnitScalars(m[k])
+		}
+		return m
+	}
+
+	switch value := value.(type) {
+	case string:
+		if durationRegexp.MatchString(value) {
+			if d, ok := parseDuration(value); ok {
+				return d
+			}
+		}
+		if unitByteSizeRegexp.MatchString(value) {
+			if size, ok := parseByteSize(value); ok {
+				return size
+			}
+		}
+		return value
+	case []interface{}:
+		for i, element := range value {
+			value[i] = coerceUnitScalars(element)
+		}
+		return value
+	default:
+		return value
+	}
+}
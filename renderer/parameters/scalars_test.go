@@ -0,0 +1,103 @@
+package parameters
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromVars_Scalars(t *testing.T) {
+	t.Run("duration", func(t *testing.T) {
+		got, err := FromVars([]string{"timeout:duration=30s", "http.retries:int=3"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"timeout": 30 * time.Second,
+			"http":    Parameters{"retries": int64(3)},
+		}, got)
+	})
+
+	t.Run("byte size", func(t *testing.T) {
+		got, err := FromVars([]string{"decimal:bytes=5MB", "binary:bytes=5Mi", "plain:bytes=512"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"decimal": int64(5000000),
+			"binary":  int64(5242880),
+			"plain":   int64(512),
+		}, got)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		RegisterScalarParser("upper", func(s string) (interface{}, bool) {
+			return strings.ToUpper(s), true
+		})
+		got, err := FromVars([]string{"name:upper=render"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"name": "RENDER"}, got)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := FromVars([]string{"timeout:duration=soon"})
+		assert.EqualError(t, err, "invalid parameter: 'timeout:duration=soon': can't parse 'soon' as 'duration'")
+	})
+
+	t.Run("unregistered parser", func(t *testing.T) {
+		_, err := FromVars([]string{"timeout:unknown=30s"})
+		assert.EqualError(t, err, "invalid parameter: 'timeout:unknown=30s': unregistered scalar parser: 'unknown'")
+	})
+
+	t.Run("plain keys with a colon", func(t *testing.T) {
+		got, err := FromVars([]string{
+			"http://localhost=up", "localhost:8080=up", "a:B=c", ":int=1", "port:=80", "db:primary.host:int=5",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"http://localhost": "up",
+			"localhost:8080":   "up",
+			"a:B":              "c",
+			":int":             "1",
+			"port:":            "80",
+			"db:primary":       Parameters{"host": int64(5)},
+		}, got)
+	})
+}
+
+func TestFromYAML_UnitScalars(t *testing.T) {
+	document := `
+timeout: 30s
+retry: 1h30m
+memory: 5Mi
+disk: 2GB
+ambiguous:
+  - 5M
+  - "30"
+  - 30 s
+  - 10ms later
+`
+
+	t.Run("coerced", func(t *testing.T) {
+		got, err := FromYAML(strings.NewReader(document), WithUnitScalars())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"timeout":   30 * time.Second,
+			"retry":     90 * time.Minute,
+			"memory":    int64(5 << 20),
+			"disk":      int64(2e9),
+			"ambiguous": []interface{}{"5M", "30", "30 s", "10ms later"},
+		}, got)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		got, err := FromTOML(strings.NewReader(`timeout = "30s"`), WithUnitScalars())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"timeout": 30 * time.Second}, got)
+	})
+
+	t.Run("without option", func(t *testing.T) {
+		got, err := FromYAML(strings.NewReader(document))
+		assert.NoError(t, err)
+		assert.Equal(t, "30s", got["timeout"])
+		assert.Equal(t, "5Mi", got["memory"])
+	})
+}
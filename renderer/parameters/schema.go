@@ -0,0 +1,44 @@
+package parameters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaError is the failure of the JSON Schema validation, with all the violations, see MergeValidated
+type SchemaError struct {
+	// Violations are the sorted violated constraints with their field paths, e.g. 'db.port: Invalid type...'
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("the parameters don't match the schema: %s", strings.Join(e.Violations, "; "))
+}
+
+// MergeValidated merges the parameter sets like Merge and validates the result with the JSON Schema document,
+// the merged parameters are returned only if they are valid, otherwise the error is a *SchemaError,
+// so a validation failure can be told apart from a merge failure or an invalid schema
+func MergeValidated(schema []byte, configs ...Parameters) (Parameters, error) {
+	merged, err := Merge(configs...)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't merge the configurations")
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(merged.Map()))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't validate with the JSON schema")
+	}
+	if !result.Valid() {
+		violations := make([]string, len(result.Errors()))
+		for i, violation := range result.Errors() {
+			violations[i] = violation.String()
+		}
+		sort.Strings(violations)
+		return nil, &SchemaError{Violations: violations}
+	}
+	return merged, nil
+}
@@ -0,0 +1,52 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeValidated(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"required": ["name", "db"],
+		"properties": {
+			"name": {"type": "string"},
+			"db": {
+				"type": "object",
+				"properties": {"port": {"type": "integer", "minimum": 1}}
+			}
+		}
+	}`)
+	base := Parameters{"name": "app", "db": Parameters{"host": "localhost", "port": 5432}}
+
+	t.Run("valid", func(t *testing.T) {
+		got, err := MergeValidated(schema, base, Parameters{"db": Parameters{"port": 5433}})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"name": "app", "db": Parameters{"host": "localhost", "port": 5433}}, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		got, err := MergeValidated(schema, base, Parameters{"name": 1, "db": Parameters{"port": 0}})
+		assert.Nil(t, got)
+		schemaErr, ok := err.(*SchemaError)
+		assert.True(t, ok, "expected a *SchemaError, got: %v", err)
+		assert.Equal(t, []string{
+			"db.port: Must be greater than or equal to 1",
+			"name: Invalid type. Expected: string, given: integer",
+		}, schemaErr.Violations)
+	})
+
+	t.Run("merge conflict", func(t *testing.T) {
+		_, err := MergeValidated(schema, base, Parameters{"db": "postgres://remote"})
+		schemaErr, ok := err.(*SchemaError)
+		assert.True(t, ok, "expected a *SchemaError, got: %v", err)
+		assert.Equal(t, []string{"db: Invalid type. Expected: object, given: string"}, schemaErr.Violations)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := MergeValidated([]byte(`{"type": 1}`), base)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "can't validate with the JSON schema")
+	})
+}
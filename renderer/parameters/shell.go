@@ -0,0 +1,57 @@
+package parameters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// shellNameRegexp matches the characters not allowed in a shell variable name
+var shellNameRegexp = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// ToShellExports turns the parameters into the sorted "export NAME='value'" lines, to be sourced by a shell script,
+// the name is the upper case flattened key (see Flatten) with the prefix, and the nesting replaced by underscores,
+// e.g. 'db.host' with the 'APP' prefix is 'APP_DB_HOST' and 'hosts[0]' is 'HOSTS_0',
+// the values are single quoted, so no shell expansion happens, nil is an empty string
+func ToShellExports(parameters Parameters, prefix string) []string {
+	var exports []string
+	for key, value := range parameters.Flatten() {
+		name := shellName(joinShellName(prefix, key))
+		exports = append(exports, fmt.Sprintf("export %s=%s", name, shellQuote(shellValue(value))))
+	}
+	sort.Strings(exports)
+	return exports
+}
+
+func joinShellName(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+func shellName(key string) string {
+	name := shellNameRegexp.ReplaceAllString(strings.ToUpper(key), "_")
+	name = strings.Trim(name, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func shellValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// shellQuote single quotes the value, every single quote in it is replaced by a closing quote, an escaped quote and an opening quote
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
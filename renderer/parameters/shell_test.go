@@ -0,0 +1,38 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToShellExports(t *testing.T) {
+	params := Parameters{
+		"db": Parameters{
+			"host":      "localhost",
+			"user-name": "admin",
+		},
+		"message": "it's $HOME `date`",
+		"debug":   true,
+		"hosts":   []interface{}{"a", "b"},
+		"empty":   nil,
+	}
+
+	t.Run("with prefix", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`export APP_DB_HOST='localhost'`,
+			`export APP_DB_USER_NAME='admin'`,
+			`export APP_DEBUG='true'`,
+			`export APP_EMPTY=''`,
+			`export APP_HOSTS_0='a'`,
+			`export APP_HOSTS_1='b'`,
+			`export APP_MESSAGE='it'\''s $HOME ` + "`date`'",
+		}, ToShellExports(params, "app"))
+	})
+
+	t.Run("without prefix", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`export DB_HOST='localhost'`,
+		}, ToShellExports(Parameters{"db": Parameters{"host": "localhost"}}, ""))
+	})
+}
@@ -0,0 +1,62 @@
+package parameters
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SOPSCommand is the sops (https://github.com/getsops/sops) executable used by FromSOPS,
+// a name looked up in the PATH or a path
+var SOPSCommand = "sops"
+
+// FromSOPS creates a configuration from a SOPS encrypted file, it is decrypted with 'sops --decrypt'
+// (see SOPSCommand) using the keys configured for sops, e.g. the SOPS_AGE_KEY_FILE environment variable,
+// the plaintext format is selected by the file extension like in FromFile,
+// all the decrypted leaves are marked as secrets in the metadata (see Metadata.MarkSecret),
+// so they are masked in the String output
+func FromSOPS(path string) (Annotated, error) {
+	loader, err := fileLoader(path)
+	if err != nil {
+		return Annotated{}, err
+	}
+
+	var stderr bytes.Buffer
+	command := exec.Command(SOPSCommand, "--decrypt", path)
+	command.Stderr = &stderr
+	plaintext, err := command.Output()
+	if err != nil {
+		logrus.Errorf("Can't decrypt the SOPS file '%s': %s", path, strings.TrimSpace(stderr.String()))
+		return Annotated{}, errors.Wrapf(err, "can't decrypt the SOPS file '%s'", path)
+	}
+
+	config, err := loader(bytes.NewReader(plaintext))
+	if err != nil {
+		return Annotated{}, errors.Wrapf(err, "can't parse the decrypted SOPS file '%s'", path)
+	}
+	result := NewAnnotated(config)
+	markSecrets(result.Metadata, config, "")
+	return result, nil
+}
+
+// markSecrets marks the dotted keys of all the leaves of the value as secrets,
+// the slice indexes are the key segments, e.g. 'users.0.token'
+func markSecrets(metadata *Metadata, value interface{}, key string) {
+	if m, ok := asMap(value); ok {
+		for _, k := range sortedKeys(m) {
+			markSecrets(metadata, m[k], joinKey(key, k))
+		}
+		return
+	}
+	if slice, ok := value.([]interface{}); ok {
+		for i, element := range slice {
+			markSecrets(metadata, element, joinKey(key, strconv.Itoa(i)))
+		}
+		return
+	}
+	metadata.MarkSecret(key)
+}
@@ -0,0 +1,59 @@
+package parameters
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSOPS replaces the sops executable with a script printing the plaintext for 'sops --decrypt <path>'
+func fakeSOPS(t *testing.T, plaintext string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sops is a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "sops")
+	content := "#!/bin/sh\n" +
+		"[ \"$1\" = \"--decrypt\" ] || { echo \"unexpected arguments: $*\" >&2; exit 2; }\n" +
+		"[ -f \"$2\" ] || { echo \"no such file: $2\" >&2; exit 1; }\n" +
+		"cat <<'EOF'\n" + plaintext + "EOF\n"
+	assert.NoError(t, os.WriteFile(script, []byte(content), 0755))
+
+	command := SOPSCommand
+	t.Cleanup(func() { SOPSCommand = command })
+	SOPSCommand = script
+}
+
+func TestFromSOPS(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "secrets.enc.yaml")
+	assert.NoError(t, os.WriteFile(encrypted, []byte("db:\n  password: ENC[AES256_GCM,data:...]\nsops: {}\n"), 0644))
+
+	t.Run("decrypted", func(t *testing.T) {
+		fakeSOPS(t, "db:\n  password: s3cr3t\n  port: 5432\nusers:\n  - token: t0k3n\n")
+
+		got, err := FromSOPS(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":    Parameters{"password": "s3cr3t", "port": 5432},
+			"users": []interface{}{Parameters{"token": "t0k3n"}},
+		}, got.Parameters)
+		assert.Equal(t, []string{"db.password", "db.port", "users.0.token"}, got.Metadata.Secrets())
+		assert.NotContains(t, got.String(), "s3cr3t")
+	})
+
+	t.Run("decryption failure", func(t *testing.T) {
+		fakeSOPS(t, "")
+
+		_, err := FromSOPS(filepath.Join(dir, "missing.yaml"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "can't decrypt the SOPS file '"+filepath.Join(dir, "missing.yaml")+"'")
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := FromSOPS(filepath.Join(dir, "secrets.xml"))
+		assert.EqualError(t, err, "unsupported configuration file extension: '"+filepath.Join(dir, "secrets.xml")+"'")
+	})
+}
@@ -0,0 +1,395 @@
+package parameters
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// VarsOption mutates the FromVars configuration
+type VarsOption func(*varsConfig)
+
+type varsConfig struct {
+	trimSpace    bool
+	flat         bool
+	nestingToken string
+	indexes      bool
+	autoIndex    bool
+}
+
+func newVarsConfig(options ...VarsOption) varsConfig {
+	var c varsConfig
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// WithTrimSpace makes FromVars strip the leading and trailing whitespace from keys and unquoted values,
+// the whitespace inside of quoted values is preserved
+func WithTrimSpace() VarsOption {
+	return func(c *varsConfig) {
+		c.trimSpace = true
+	}
+}
+
+// WithIndexes makes FromVars create the slices at the bracket indices, e.g. 'hosts[1]=b' sets the second element
+// of 'hosts' and the missing ones are nil, without it the brackets are a part of the key, e.g. 'labels[app]=web'
+func WithIndexes() VarsOption {
+	return func(c *varsConfig) {
+		c.indexes = true
+	}
+}
+
+// WithAutoIndex makes FromVars append the values of the keys with the empty brackets to the slices, it implies
+// WithIndexes, e.g. 'servers[]=a servers[]=b' is the same as 'servers[0]=a servers[1]=b'. The empty brackets always
+// append after the current last element, also the one set by an explicit index, so 'servers[]=a servers[5]=z
+// servers[]=b' is '[a, nil, nil, nil, nil, z, b]', and a later explicit index overrides an appended element
+func WithAutoIndex() VarsOption {
+	return func(c *varsConfig) {
+		c.indexes = true
+		c.autoIndex = true
+	}
+}
+
+// VarInfo describes a single extra variable (key=value) as parsed by FromVars
+type VarInfo struct {
+	// PathSegments are the dot separated parts of the key
+	PathSegments []string
+	// Value is the value as it would be stored, without the surrounding quotes
+	Value string
+	// Quoted is true if the value was surrounded by matching single or double quotes
+	Quoted bool
+	// HadEqualsInValue is true if the value contains an equals sign
+	HadEqualsInValue bool
+	// Scalar is the name of the scalar parser from the 'key:name=value' syntax, see RegisterScalarParser,
+	// empty if the key doesn't end with a colon followed by the lower case letters
+	Scalar string
+}
+
+// WithNestingToken makes FromVars also nest the keys at the token, in addition to the dots,
+// e.g. with '__' the 'DB__HOST=x' variable is the same as 'DB.HOST=x', the values are not affected
+func WithNestingToken(token string) VarsOption {
+	return func(c *varsConfig) {
+		c.nestingToken = token
+	}
+}
+
+// AnalyzeVar parses a single extra variable (key=value) without building the parameters tree,
+// it accepts the same options as FromVars
+func AnalyzeVar(v string, options ...VarsOption) (VarInfo, error) {
+	return newVarsConfig(options...).analyze(v)
+}
+
+func (c varsConfig) analyze(v string) (VarInfo, error) {
+	argRegexp := VarArgRegexp
+	if c.trimSpace {
+		argRegexp = VarArgTrimRegexp
+	}
+
+	groups, ok := argRegexp.MatchGroups(v)
+	if !ok {
+		logrus.Errorf("Expected a valid extra parameter: '%s'", v)
+		return VarInfo{}, errors.Errorf("invalid parameter: '%s'", v)
+	}
+	name := groups["name"]
+	if len(name) == 0 {
+		logrus.Errorf("Expected a non-empty extra parameter key: '%s'", v)
+		return VarInfo{}, errors.Errorf("invalid parameter, empty key: '%s'", v)
+	}
+	var scalar string
+	if i := strings.LastIndex(name, ":"); i > 0 && isScalarName(name[i+1:]) {
+		name, scalar = name[:i], name[i+1:]
+	}
+	rawValue := groups["value"]
+	return VarInfo{
+		PathSegments:     c.pathSegments(name),
+		Scalar:           scalar,
+		Value:            strings.Trim(rawValue, `"'`),
+		Quoted:           isQuoted(rawValue),
+		HadEqualsInValue: strings.Contains(rawValue, "="),
+	}, nil
+}
+
+func (c varsConfig) pathSegments(name string) []string {
+	segments := strings.Split(name, ".")
+	if len(c.nestingToken) == 0 {
+		return segments
+	}
+	var split []string
+	for _, segment := range segments {
+		split = append(split, strings.Split(segment, c.nestingToken)...)
+	}
+	return split
+}
+
+func isQuoted(value string) bool {
+	if len(value) < 2 {
+		return false
+	}
+	first, last := value[0], value[len(value)-1]
+	return first == last && (first == '"' || first == '\'')
+}
+
+// FromArgs creates a configuration from the command line style arguments:
+// '--key=value', '--key value' and '--flag' (a boolean true, also when it is the last argument),
+// the dotted keys are nested (e.g. '--db.host=localhost')
+func FromArgs(args []string) (Parameters, error) {
+	var config = Parameters{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			logrus.Errorf("Expected a valid argument: '%s'", arg)
+			return nil, errors.Errorf("invalid argument: '%s'", arg)
+		}
+		name := strings.TrimPrefix(arg, "--")
+
+		var value interface{}
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value = name[:j], name[j+1:]
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			value = args[i+1]
+			i++
+		} else {
+			value = true
+		}
+		if len(name) == 0 {
+			logrus.Errorf("Expected a non-empty argument name: '%s'", arg)
+			return nil, errors.Errorf("invalid argument, empty name: '%s'", arg)
+		}
+
+		logrus.Debugf("Argument: %s=%v", name, value)
+		err := config.Set(name, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid argument: '%s'", arg)
+		}
+	}
+
+	logrus.Debugf("Parameters from args: %v", config)
+	return config, nil
+}
+
+// varStep is a single step of an indexed variable key, a map key or a slice index,
+// the negative index appends to the slice
+type varStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// setIndexed sets the value at the dotted key with the bracket indices (e.g. 'servers[0].host'),
+// creating the missing maps and slices, the slices are extended with nil elements,
+// with autoIndex the empty brackets (e.g. 'servers[]') append to the slice
+func setIndexed(parameters *Parameters, key string, value interface{}, autoIndex bool) error {
+	var steps []varStep
+	for _, part := range strings.Split(key, ".") {
+		name := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return errors.Errorf("invalid index in key: '%s'", key)
+			}
+			name = part[:i]
+			indexes = strings.Split(part[i+1:len(part)-1], "][")
+		}
+		if len(name) == 0 {
+			return errors.Errorf("invalid key: '%s', empty key segment", key)
+		}
+		steps = append(steps, varStep{key: name})
+		for _, index := range indexes {
+			if autoIndex && index == "" {
+				steps = append(steps, varStep{index: -1, isIndex: true})
+				continue
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 {
+				return errors.Errorf("invalid index in key: '%s'", key)
+			}
+			steps = append(steps, varStep{index: i, isIndex: true})
+		}
+	}
+
+	_, err := setSteps(*parameters, steps, value)
+	return err
+}
+
+func setSteps(container interface{}, steps []varStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	step := steps[0]
+
+	if step.isIndex {
+		if container == nil {
+			container = []interface{}{}
+		}
+		slice, ok := container.([]interface{})
+		if !ok && step.index < 0 {
+			return nil, errors.Errorf(
+				"key conflict: can't append, the value is not a slice, it has type: '%s'",
+				reflect.TypeOf(container))
+		}
+		if !ok {
+			return nil, errors.Errorf(
+				"key conflict: index %d can't be set, the value is not a slice, it has type: '%s'",
+				step.index, reflect.TypeOf(container))
+		}
+		index := step.index
+		if index < 0 {
+			index = len(slice)
+		}
+		for len(slice) <= index {
+			slice = append(slice, nil)
+		}
+		element, err := setSteps(slice[index], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		slice[index] = element
+		return slice, nil
+	}
+
+	if container == nil {
+		container = Parameters{}
+	}
+	m, ok := asMap(container)
+	if !ok {
+		return nil, errors.Errorf(
+			"key conflict: key '%s' can't be set, the value is not a map, it has type: '%s'",
+			step.key, reflect.TypeOf(container))
+	}
+	element, err := setSteps(m[step.key], steps[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	m[step.key] = element
+	return container, nil
+}
+
+// ToVars turns the parameters into the sorted extra variables (key=value) that FromVars with WithIndexes
+// turns back into the same parameters, the keys are flattened (see Flatten) with the bracket indices for the slices,
+// the values other than strings are annotated with their scalar parsers (e.g. 'port:int=5432'),
+// the ints become int64, and the values with spaces are double quoted.
+// The nil values, the empty maps and slices, the strings starting or ending with a quote
+// and the keys with ':', '=' or '[' can't be represented exactly
+func ToVars(parameters Parameters) []string {
+	var vars []string
+	for key, value := range parameters.Flatten() {
+		if value == nil {
+			continue
+		}
+		if _, ok := asMap(value); ok {
+			continue
+		}
+		if _, ok := value.([]interface{}); ok {
+			continue
+		}
+		vars = append(vars, toVar(key, value))
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+func toVar(key string, value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		if strings.Contains(value, " ") {
+			return key + `="` + value + `"`
+		}
+		return key + "=" + value
+	case bool:
+		return key + ":bool=" + strconv.FormatBool(value)
+	case time.Duration:
+		return key + ":duration=" + value.String()
+	}
+	if number, integer, ok := asNumber(value); ok {
+		if integer {
+			return fmt.Sprintf("%s:int=%v", key, value)
+		}
+		return key + ":float=" + strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%s=%v", key, value)
+}
+
+// nestedTree sets the dotted keys like appendNested, but it remembers the parent maps by their dotted key,
+// so the many keys sharing a prefix don't traverse and look up the same parents again
+type nestedTree struct {
+	root    Parameters
+	parents map[string]Parameters
+}
+
+func newNestedTree(root Parameters) *nestedTree {
+	return &nestedTree{root: root, parents: map[string]Parameters{}}
+}
+
+// set sets the value of the dotted key, the later value wins and a parent that isn't a map is a key conflict
+func (tree *nestedTree) set(key string, value interface{}) error {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		tree.assign(tree.root, key, key, value)
+		return nil
+	}
+	if parent, ok := tree.parents[key[:i]]; ok {
+		tree.assign(parent, key, key[i+1:], value)
+		return nil
+	}
+
+	// start from the deepest remembered parent
+	current, start := tree.root, 0
+	for j := i; j > 0; j = strings.LastIndex(key[:j], ".") {
+		if parent, ok := tree.parents[key[:j]]; ok {
+			current, start = parent, j+1
+			break
+		}
+	}
+	for {
+		end := strings.Index(key[start:], ".")
+		if end < 0 {
+			break
+		}
+		end += start
+		name := key[start:end]
+		existing, ok := current[name]
+		if !ok {
+			current[name] = Parameters{}
+		}
+		next, ok := current[name].(Parameters)
+		if existing != nil && !ok {
+			return errors.Errorf(
+				"key conflict: key '%s' already exists and is not a map, it has type: '%s'",
+				name, reflect.TypeOf(existing))
+		}
+		if ok {
+			tree.parents[key[:end]] = next
+		}
+		current, start = next, end+1
+	}
+	tree.assign(current, key, key[start:], value)
+	return nil
+}
+
+// assign sets the last segment of the dotted key and forgets the parents replaced by the value
+func (tree *nestedTree) assign(parent Parameters, key, name string, value interface{}) {
+	parent[name] = value
+	if _, ok := tree.parents[key]; !ok {
+		return
+	}
+	for parentKey := range tree.parents {
+		if parentKey == key || strings.HasPrefix(parentKey, key+".") {
+			delete(tree.parents, parentKey)
+		}
+	}
+}
+
+// reset forgets all the parents, e.g. after the tree was changed by something else
+func (tree *nestedTree) reset() {
+	if len(tree.parents) > 0 {
+		tree.parents = map[string]Parameters{}
+	}
+}
@@ -0,0 +1,341 @@
+package parameters
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VirtusLab/go-extended/pkg/matcher"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVarArgRegexp(t *testing.T) {
+	// legacy is the format before the key ended at the first equals sign
+	legacy := matcher.Must(`^(?P<name>\S+)=(?P<value>[\S ]*)$`)
+	tests := []struct {
+		input       string
+		name, value string
+		ok          bool
+		legacyName  string
+		legacyValue string
+		legacyOK    bool
+	}{
+		{input: "key=value", name: "key", value: "value", ok: true, legacyName: "key", legacyValue: "value", legacyOK: true},
+		{input: "key=", name: "key", value: "", ok: true, legacyName: "key", legacyValue: "", legacyOK: true},
+		{input: "db.host=a b", name: "db.host", value: "a b", ok: true, legacyName: "db.host", legacyValue: "a b", legacyOK: true},
+		{input: "query=a=b", name: "query", value: "a=b", ok: true, legacyName: "query=a", legacyValue: "b", legacyOK: true},
+		{input: "key==value", name: "key", value: "=value", ok: true, legacyName: "key=", legacyValue: "value", legacyOK: true},
+		{input: "=a=b", ok: false, legacyName: "=a", legacyValue: "b", legacyOK: true},
+		{input: "=value", ok: false, legacyOK: false},
+		{input: "no-equals", ok: false, legacyOK: false},
+		{input: "a key=value", ok: false, legacyOK: false},
+		{input: "key=a\tb", ok: false, legacyOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			groups, ok := VarArgRegexp.MatchGroups(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.name, groups["name"])
+				assert.Equal(t, tt.value, groups["value"])
+			}
+
+			groups, ok = legacy.MatchGroups(tt.input)
+			assert.Equal(t, tt.legacyOK, ok, "legacy")
+			if ok {
+				assert.Equal(t, tt.legacyName, groups["name"], "legacy")
+				assert.Equal(t, tt.legacyValue, groups["value"], "legacy")
+			}
+		})
+	}
+}
+
+func TestAnalyzeVar(t *testing.T) {
+	t.Run("nested quoted value", func(t *testing.T) {
+		got, err := AnalyzeVar(`db.primary.host="a host"`)
+		assert.NoError(t, err)
+		assert.Equal(t, VarInfo{
+			PathSegments:     []string{"db", "primary", "host"},
+			Value:            "a host",
+			Quoted:           true,
+			HadEqualsInValue: false,
+		}, got)
+	})
+
+	t.Run("equals in value", func(t *testing.T) {
+		got, err := AnalyzeVar(`query=a=b&c=d`)
+		assert.NoError(t, err)
+		assert.Equal(t, VarInfo{
+			PathSegments:     []string{"query"},
+			Value:            "a=b&c=d",
+			Quoted:           false,
+			HadEqualsInValue: true,
+		}, got)
+
+		params, err := FromVars([]string{`query=a=b&c=d`})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"query": "a=b&c=d"}, params)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := AnalyzeVar(`no-equals`)
+		assert.EqualError(t, err, "invalid parameter: 'no-equals'")
+	})
+}
+
+func TestFromArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Parameters
+		wantErr string
+	}{
+		{
+			name: "equals",
+			args: []string{"--key=value", "--db.host=localhost", "--query=a=b"},
+			want: Parameters{
+				"key":   "value",
+				"db":    Parameters{"host": "localhost"},
+				"query": "a=b",
+			},
+		}, {
+			name: "space separated",
+			args: []string{"--key", "value", "--db.port", "5432"},
+			want: Parameters{
+				"key": "value",
+				"db":  Parameters{"port": "5432"},
+			},
+		}, {
+			name: "boolean flag",
+			args: []string{"--verbose", "--key", "value"},
+			want: Parameters{
+				"verbose": true,
+				"key":     "value",
+			},
+		}, {
+			name: "dangling flag",
+			args: []string{"--key", "value", "--db.debug"},
+			want: Parameters{
+				"key": "value",
+				"db":  Parameters{"debug": true},
+			},
+		}, {
+			name:    "positional",
+			args:    []string{"value"},
+			wantErr: "invalid argument: 'value'",
+		}, {
+			name:    "empty name",
+			args:    []string{"--=value"},
+			wantErr: "invalid argument, empty name: '--=value'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromArgs(tt.args)
+			if len(tt.wantErr) > 0 {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromVars_Indexed(t *testing.T) {
+	t.Run("slices", func(t *testing.T) {
+		got, err := FromVars([]string{"tags[1]=b", "tags[0]=a", "servers[0].ports[2]=443", "servers[0].name=web"}, WithIndexes())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"tags": []interface{}{"a", "b"},
+			"servers": []interface{}{
+				Parameters{"name": "web", "ports": []interface{}{nil, nil, "443"}},
+			},
+		}, got)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := FromVars([]string{"tags=a", "tags[0]=b"}, WithIndexes())
+		assert.EqualError(t, err, "invalid parameter: 'tags[0]=b': "+
+			"key conflict: index 0 can't be set, the value is not a slice, it has type: 'string'")
+	})
+
+	t.Run("invalid index", func(t *testing.T) {
+		_, err := FromVars([]string{"tags[x]=a"}, WithIndexes())
+		assert.EqualError(t, err, "invalid parameter: 'tags[x]=a': invalid index in key: 'tags[x]'")
+	})
+
+	t.Run("literal brackets without the option", func(t *testing.T) {
+		got, err := FromVars([]string{"labels[app]=web", "tags[0]=a", "db.hosts[1].name=x"})
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"labels[app]": "web",
+			"tags[0]":     "a",
+			"db":          Parameters{"hosts[1]": Parameters{"name": "x"}},
+		}, got)
+	})
+}
+
+func TestFromVars_AutoIndex(t *testing.T) {
+	t.Run("appends", func(t *testing.T) {
+		got, err := FromVars([]string{"servers[]=a", "servers[]=b"}, WithAutoIndex())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"servers": []interface{}{"a", "b"}}, got)
+	})
+
+	t.Run("mixed with explicit index", func(t *testing.T) {
+		got, err := FromVars([]string{"servers[]=a", "servers[5]=z", "servers[]=b", "servers[0]=x"}, WithAutoIndex())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{"servers": []interface{}{"x", nil, nil, nil, nil, "z", "b"}}, got)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		got, err := FromVars([]string{"db.hosts[]=a", "servers[].name=web", "servers[].name=db"}, WithAutoIndex())
+		assert.NoError(t, err)
+		assert.Equal(t, Parameters{
+			"db":      Parameters{"hosts": []interface{}{"a"}},
+			"servers": []interface{}{Parameters{"name": "web"}, Parameters{"name": "db"}},
+		}, got)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := FromVars([]string{"servers=a", "servers[]=b"}, WithAutoIndex())
+		assert.EqualError(t, err, "invalid parameter: 'servers[]=b': "+
+			"key conflict: can't append, the value is not a slice, it has type: 'string'")
+	})
+
+	t.Run("auto index without WithAutoIndex", func(t *testing.T) {
+		_, err := FromVars([]string{"servers[]=a"}, WithIndexes())
+		assert.EqualError(t, err, "invalid parameter: 'servers[]=a': invalid index in key: 'servers[]'")
+	})
+}
+
+// fromVarsStraightforward is FromVars setting every nested key from the root with appendNested
+func fromVarsStraightforward(vars []string) (Parameters, error) {
+	c := newVarsConfig()
+	var config = &Parameters{}
+	for _, v := range vars {
+		info, err := c.analyze(v)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.Join(info.PathSegments, ".")
+		if strings.Contains(name, "[") {
+			err = setIndexed(config, name, info.Value, false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid parameter: '%s'", v)
+			}
+		} else if strings.Contains(name, ".") {
+			config, err = appendNested(config, name, info.Value)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			(*config)[name] = info.Value
+		}
+	}
+	return *config, nil
+}
+
+// randomVars returns the vars with the keys from a small set of segments, so they share the prefixes,
+// overwrite each other and conflict
+func randomVars(random *rand.Rand, n int) []string {
+	segments := []string{"a", "b", "c", "d"}
+	vars := make([]string, n)
+	for i := range vars {
+		depth := 1 + random.Intn(4)
+		keys := make([]string, depth)
+		for j := range keys {
+			keys[j] = segments[random.Intn(len(segments))]
+		}
+		if random.Intn(20) == 0 {
+			keys[random.Intn(depth)] += fmt.Sprintf("[%d]", random.Intn(3))
+		}
+		vars[i] = fmt.Sprintf("%s=%d", strings.Join(keys, "."), i)
+	}
+	return vars
+}
+
+func TestFromVars_Randomized(t *testing.T) {
+	for seed := int64(0); seed < 2000; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		vars := randomVars(random, 1+random.Intn(30))
+
+		want, wantErr := fromVarsStraightforward(vars)
+		got, err := FromVars(vars, WithIndexes())
+		if wantErr != nil {
+			assert.EqualError(t, err, wantErr.Error(), "vars: %v", vars)
+			continue
+		}
+		assert.NoError(t, err, "vars: %v", vars)
+		assert.Equal(t, want, got, "vars: %v", vars)
+	}
+}
+
+func benchmarkVars(n int) []string {
+	vars := make([]string, n)
+	for i := range vars {
+		vars[i] = fmt.Sprintf("services.service%d.config.key%d=%d", i/100, i%100, i)
+	}
+	return vars
+}
+
+func BenchmarkFromVars(b *testing.B) {
+	vars := benchmarkVars(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := FromVars(vars)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFromVars_Straightforward(b *testing.B) {
+	vars := benchmarkVars(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := fromVarsStraightforward(vars)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestToVars(t *testing.T) {
+	params := Parameters{
+		"name": "render",
+		"db": Parameters{
+			"host":    "a host",
+			"port":    int64(5432),
+			"ssl":     true,
+			"ratio":   0.5,
+			"timeout": 30 * time.Second,
+		},
+		"servers": []interface{}{
+			Parameters{"name": "web", "tags": []interface{}{"a", "b"}},
+			"cron",
+		},
+	}
+
+	vars := ToVars(params)
+	assert.Equal(t, []string{
+		`db.host="a host"`,
+		"db.port:int=5432",
+		"db.ratio:float=0.5",
+		"db.ssl:bool=true",
+		"db.timeout:duration=30s",
+		"name=render",
+		"servers[0].name=web",
+		"servers[0].tags[0]=a",
+		"servers[0].tags[1]=b",
+		"servers[1]=cron",
+	}, vars)
+
+	got, err := FromVars(vars, WithIndexes())
+	assert.NoError(t, err)
+	assert.Equal(t, params, got)
+}
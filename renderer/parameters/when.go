@@ -0,0 +1,173 @@
+package parameters
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// WhenKey is the key of the guard expression of a guarded value, see ResolveWhen
+	WhenKey = "when"
+	// WhenValueKey is the key of the value of a guarded value, see ResolveWhen
+	WhenValueKey = "value"
+)
+
+// WithWhenGuards makes FromYAML and FromJSON resolve the guarded values with the context, see ResolveWhen
+func WithWhenGuards(context Parameters) LoadOption {
+	return func(c *loadConfig) {
+		c.whenGuards = true
+		c.whenContext = context
+	}
+}
+
+// ResolveWhen returns a copy of the parameters with the guarded values resolved, a guarded value is a map
+// with exactly the 'value' and the 'when' keys e.g. '{value: x, when: "env == prod"}', it is replaced by the value
+// if the guard holds, otherwise its key (or slice element) is dropped.
+// The guard is '<key> == <literal>' or '<key> != <literal>', the literal can be double quoted,
+// the key is looked up in the context first, then in the parameters, a missing key compares as an empty string
+func ResolveWhen(parameters, context Parameters) (Parameters, error) {
+	resolved, _, err := resolveWhenMarked(parameters, context, nil)
+	return resolved, err
+}
+
+// resolveWhenMarked resolves the guards like ResolveWhen and returns the sorted dotted keys the marked dotted keys
+// (e.g. the secrets) of the unresolved parameters have after the resolution, the dropped ones are skipped
+func resolveWhenMarked(parameters, context Parameters, marked []string) (Parameters, []string, error) {
+	w := &whenResolver{
+		parameters: parameters,
+		context:    context,
+		marked:     make(map[string]bool, len(marked)),
+		moved:      map[string]bool{},
+	}
+	for _, key := range marked {
+		w.marked[key] = true
+	}
+	resolved, _, err := w.resolve(map[string]interface{}(parameters), "", nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, _ := asMap(resolved)
+	keys := make([]string, 0, len(w.moved))
+	for key := range w.moved {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return FromMap(m), keys, nil
+}
+
+// whenResolver resolves the guarded values, it follows the marked keys from their source to the resolved paths
+type whenResolver struct {
+	parameters Parameters
+	context    Parameters
+	marked     map[string]bool
+	moved      map[string]bool
+}
+
+// resolve returns the value with the guards resolved and false if the value is dropped,
+// the source and the target are the key segments of the value before and after the resolution
+func (w *whenResolver) resolve(value interface{}, path string, source, target []string) (interface{}, bool, error) {
+	resolved, keep, err := w.resolveValue(value, path, source, target)
+	if keep && len(source) > 0 && w.marked[strings.Join(source, ".")] {
+		w.moved[strings.Join(target, ".")] = true
+	}
+	return resolved, keep, err
+}
+
+func (w *whenResolver) resolveValue(value interface{}, path string, source, target []string) (interface{}, bool, error) {
+	if m, ok := asMap(value); ok {
+		if guard, guarded := whenGuard(m); guarded {
+			holds, err := evaluateWhen(guard, w.parameters, w.context)
+			if err != nil {
+				return nil, false, errors.Wrapf(err, "invalid '%s' guard of '%s'", WhenKey, path)
+			}
+			if !holds {
+				return nil, false, nil
+			}
+			return w.resolve(m[WhenValueKey], path, append(source[:len(source):len(source)], WhenValueKey), target)
+		}
+
+		resolved := make(map[string]interface{}, len(m))
+		for _, k := range sortedKeys(m) {
+			sourcePath := append(source[:len(source):len(source)], k)
+			targetPath := append(target[:len(target):len(target)], k)
+			v, keep, err := w.resolve(m[k], joinKey(path, k), sourcePath, targetPath)
+			if err != nil {
+				return nil, false, err
+			}
+			if keep {
+				resolved[k] = v
+			}
+		}
+		return resolved, true, nil
+	}
+
+	if slice, ok := value.([]interface{}); ok {
+		resolved := make([]interface{}, 0, len(slice))
+		for i, element := range slice {
+			sourcePath := append(source[:len(source):len(source)], strconv.Itoa(i))
+			targetPath := append(target[:len(target):len(target)], strconv.Itoa(len(resolved)))
+			v, keep, err := w.resolve(element, fmt.Sprintf("%s[%d]", path, i), sourcePath, targetPath)
+			if err != nil {
+				return nil, false, err
+			}
+			if keep {
+				resolved = append(resolved, v)
+			}
+		}
+		return resolved, true, nil
+	}
+	return value, true, nil
+}
+
+// whenGuard returns the guard expression if the map is a guarded value
+func whenGuard(m map[string]interface{}) (interface{}, bool) {
+	if len(m) != 2 {
+		return nil, false
+	}
+	guard, hasWhen := m[WhenKey]
+	_, hasValue := m[WhenValueKey]
+	return guard, hasWhen && hasValue
+}
+
+func evaluateWhen(guard interface{}, parameters, context Parameters) (bool, error) {
+	expression, ok := guard.(string)
+	if !ok {
+		return false, errors.Errorf("expected a 'string', got: '%T'", guard)
+	}
+
+	operator := "=="
+	i := strings.Index(expression, operator)
+	if i < 0 {
+		operator = "!="
+		i = strings.Index(expression, operator)
+	}
+	if i < 0 {
+		return false, errors.Errorf("expected '<key> == <literal>' or '<key> != <literal>', got: '%s'", expression)
+	}
+	key := strings.TrimSpace(expression[:i])
+	literal := strings.TrimSpace(expression[i+len(operator):])
+	if key == "" || literal == "" || strings.ContainsAny(key, " \t=!") {
+		return false, errors.Errorf("expected '<key> == <literal>' or '<key> != <literal>', got: '%s'", expression)
+	}
+	if strings.HasPrefix(literal, `"`) {
+		unquoted, err := strconv.Unquote(literal)
+		if err != nil {
+			return false, errors.Errorf("invalid quoted literal: '%s'", literal)
+		}
+		literal = unquoted
+	}
+
+	value, ok := context.Get(key)
+	if !ok {
+		value, ok = parameters.Get(key)
+	}
+	actual := ""
+	if ok && value != nil {
+		actual = fmt.Sprint(value)
+	}
+	return (actual == literal) == (operator == "=="), nil
+}
@@ -0,0 +1,279 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"text/template/parse"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+
+	"github.com/VirtusLab/go-extended/pkg/renderer/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultConfigurators returns the configuration used by the package level render functions
+func defaultConfigurators(params parameters.Parameters) []func(*config.Config) {
+	return []func(*config.Config){
+		WithParameters(params),
+		WithSprigFunctions(),
+		WithExtraFunctions(),
+		WithNetFunctions(),
+		systemTimeFunctions(),
+		WithRegisteredFunctions(),
+	}
+}
+
+// RenderTemplate renders the template with the parameters and the Sprig, extra, net
+// and registered (see RegisterFunc) template functions
+func RenderTemplate(tmpl string, params parameters.Parameters) (string, error) {
+	return New(defaultConfigurators(params)...).Render(tmpl)
+}
+
+// RenderValue renders the template like RenderTemplate, but any value can be the template data (the dot),
+// e.g. a top level slice iterated with '{{ range . }}' or a scalar, the maps are rendered as parameters
+func RenderValue(tmpl string, data interface{}) (string, error) {
+	switch data := data.(type) {
+	case parameters.Parameters:
+		return RenderTemplate(tmpl, data)
+	case map[string]interface{}:
+		return RenderTemplate(tmpl, data)
+	}
+
+	r := New(defaultConfigurators(parameters.Parameters{})...)
+	t, err := r.Parse("value", tmpl, r.Configuration().ExtraFunctions)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = t.Execute(&buffer, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't render the template with the data of type: '%T'", data)
+	}
+	return buffer.String(), nil
+}
+
+// RenderWithCleanup renders the template like RenderTemplate and, if the cleanup is true,
+// removes the trailing whitespace of every line and collapses the runs of blank lines into a single blank line,
+// e.g. the ones left by the template actions, unlike the '{{-' and '-}}' trimming it works on the whole output
+func RenderWithCleanup(tmpl string, params parameters.Parameters, cleanup bool) (string, error) {
+	result, err := RenderTemplate(tmpl, params)
+	if err != nil || !cleanup {
+		return result, err
+	}
+	return cleanupOutput(result), nil
+}
+
+func cleanupOutput(output string) string {
+	lines := strings.Split(output, "\n")
+	cleaned := make([]string, 0, len(lines))
+	blank := false
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && i < len(lines)-1 {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		cleaned = append(cleaned, line)
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+// RenderGzip renders the template like RenderTemplate, streaming the output through a gzip writer into the writer,
+// the gzip stream is closed also on a render error, so the writer always gets a valid (possibly truncated) stream
+func RenderGzip(w io.Writer, tmpl string, params parameters.Parameters) (err error) {
+	gz := gzip.NewWriter(w)
+	defer func() {
+		closeErr := gz.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "can't close the gzip stream")
+		}
+	}()
+
+	r := New(defaultConfigurators(params)...).(*renderer)
+	t, err := r.prepare("gzip", tmpl)
+	if err != nil {
+		return err
+	}
+	err = t.Execute(gz, r.Configuration().Parameters)
+	if err != nil {
+		return errors.Wrapf(err, "can't render the template '%s'", t.Name())
+	}
+	return nil
+}
+
+// RenderBounded renders the template like RenderTemplate, but fails as soon as the output exceeds
+// the maximum number of bytes, e.g. to protect the memory from a runaway 'range', the output is never
+// buffered beyond the limit
+func RenderBounded(tmpl string, params parameters.Parameters, maxBytes int) (string, error) {
+	r := New(defaultConfigurators(params)...).(*renderer)
+	t, err := r.prepare("bounded", tmpl)
+	if err != nil {
+		return "", err
+	}
+	w := &boundedWriter{max: maxBytes}
+	err = t.Execute(w, r.Configuration().Parameters)
+	if w.exceeded {
+		return "", errors.Errorf("the rendered output exceeds the limit of %d bytes, %d bytes written and %d more attempted",
+			maxBytes, w.buffer.Len(), w.attempted)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "can't render the template '%s'", t.Name())
+	}
+	return w.buffer.String(), nil
+}
+
+// boundedWriter buffers up to the maximum number of bytes, a write over the limit fails and stops the rendering
+type boundedWriter struct {
+	buffer    bytes.Buffer
+	max       int
+	exceeded  bool
+	attempted int
+}
+
+func (w *boundedWriter) Write(p []byte) (int, error) {
+	if w.buffer.Len()+len(p) > w.max {
+		w.exceeded = true
+		w.attempted = len(p)
+		return 0, errors.Errorf("the output limit of %d bytes exceeded", w.max)
+	}
+	return w.buffer.Write(p)
+}
+
+// RenderTwoPhase renders the parameters template first, loads its output as a YAML document of derived parameters,
+// merges it on top of the base parameters and renders the main template with the result
+func RenderTwoPhase(paramsTmpl, mainTmpl string, base parameters.Parameters) (string, error) {
+	derivedYAML, err := RenderTemplate(paramsTmpl, base)
+	if err != nil {
+		return "", errors.Wrap(err, "parameters phase: can't render the parameters template")
+	}
+	derived, err := parameters.FromYAML(strings.NewReader(derivedYAML))
+	if err != nil {
+		return "", errors.Wrap(err, "parameters phase: the parameters template output is not a valid YAML")
+	}
+	logrus.Debugf("Derived parameters: %v", derived)
+
+	merged, err := parameters.Merge(base, derived)
+	if err != nil {
+		return "", errors.Wrap(err, "parameters phase: can't merge the derived parameters")
+	}
+	result, err := RenderTemplate(mainTmpl, merged)
+	if err != nil {
+		return "", errors.Wrap(err, "main phase: can't render the main template")
+	}
+	return result, nil
+}
+
+// RenderPartial renders the template with the parameters, but the actions referencing
+// missing keys are emitted verbatim (e.g. '{{ .missing }}') instead of failing,
+// so the output can be rendered again in a later pass with more parameters.
+// Only the actions evaluated against the root (outside of 'range' and 'with') are preserved.
+func RenderPartial(tmpl string, params parameters.Parameters) (string, error) {
+	r := New(defaultConfigurators(params)...)
+	c := r.Configuration()
+	t, err := r.Parse("partial", tmpl, c.ExtraFunctions)
+	if err != nil {
+		return "", err
+	}
+	if t.Tree != nil {
+		preserveMissing(t.Tree.Root, tmpl, c.LeftDelim, c.RightDelim, params)
+	}
+	return r.Execute(t)
+}
+
+// preserveMissing replaces the actions referencing missing keys with their original text
+func preserveMissing(list *parse.ListNode, tmpl, leftDelim, rightDelim string, params parameters.Parameters) {
+	if list == nil {
+		return
+	}
+	for i, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.ActionNode:
+			if !referencesMissing(node.Pipe, params) {
+				continue
+			}
+			list.Nodes[i] = &parse.TextNode{
+				NodeType: parse.NodeText,
+				Pos:      node.Pos,
+				Text:     []byte(actionText(tmpl, node, leftDelim, rightDelim)),
+			}
+		case *parse.IfNode:
+			preserveMissing(node.List, tmpl, leftDelim, rightDelim, params)
+			preserveMissing(node.ElseList, tmpl, leftDelim, rightDelim, params)
+		}
+	}
+}
+
+// actionText returns the original text of the action, including the delimiters, the right delimiter
+// is looked up after the last token of the parse tree, so it is never one inside of a string literal
+func actionText(tmpl string, action *parse.ActionNode, leftDelim, rightDelim string) string {
+	pos := int(action.Pos)
+	start := strings.LastIndex(tmpl[:pos], leftDelim)
+	last := tokenEnd(action.Pipe)
+	end := strings.Index(tmpl[last:], rightDelim)
+	if start < 0 || end < 0 {
+		return ""
+	}
+	return tmpl[start : last+end+len(rightDelim)]
+}
+
+// tokenEnd returns the template offset after the last token of the node, or its position
+// for the tokens that can't contain a delimiter (e.g. the fields and the identifiers)
+func tokenEnd(node parse.Node) int {
+	end := int(node.Position())
+	var children []parse.Node
+	switch node := node.(type) {
+	case *parse.StringNode:
+		return end + len(node.Quoted)
+	case *parse.NumberNode:
+		return end + len(node.Text)
+	case *parse.PipeNode:
+		for _, variable := range node.Decl {
+			children = append(children, variable)
+		}
+		for _, command := range node.Cmds {
+			children = append(children, command)
+		}
+	case *parse.CommandNode:
+		children = node.Args
+	case *parse.ChainNode:
+		children = []parse.Node{node.Node}
+	}
+	for _, child := range children {
+		if childEnd := tokenEnd(child); childEnd > end {
+			end = childEnd
+		}
+	}
+	return end
+}
+
+func referencesMissing(pipe *parse.PipeNode, params parameters.Parameters) bool {
+	if pipe == nil {
+		return false
+	}
+	for _, command := range pipe.Cmds {
+		for _, arg := range command.Args {
+			switch arg := arg.(type) {
+			case *parse.FieldNode:
+				if !params.Exists(strings.Join(arg.Ident, ".")) {
+					return true
+				}
+			case *parse.VariableNode:
+				if len(arg.Ident) > 1 && arg.Ident[0] == "$" && !params.Exists(strings.Join(arg.Ident[1:], ".")) {
+					return true
+				}
+			case *parse.PipeNode:
+				if referencesMissing(arg, params) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package renderer
+
+import (
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+// ReferencedKeys parses the template, without executing it, and returns the sorted dotted keys
+// of the parameters it references, e.g. '{{ .a.b }}' references 'a.b'.
+// Inside of 'range' and 'with' the dot is the current element, so only the ranged key
+// and the keys referenced by '$' (e.g. '{{ $.a.b }}') are returned
+func ReferencedKeys(tmpl string) ([]string, error) {
+	tree := parse.New("referenced")
+	tree.Mode = parse.SkipFuncCheck
+	_, err := tree.Parse(tmpl, "", "", map[string]*parse.Tree{})
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse the template")
+	}
+
+	keys := map[string]bool{}
+	referencedInList(tree.Root, false, keys)
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// referencedInList collects the keys referenced in the list, the fields are ignored if the dot is not the root
+func referencedInList(list *parse.ListNode, nested bool, keys map[string]bool) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.ActionNode:
+			referencedInPipe(node.Pipe, nested, keys)
+		case *parse.TemplateNode:
+			referencedInPipe(node.Pipe, nested, keys)
+		case *parse.IfNode:
+			referencedInPipe(node.Pipe, nested, keys)
+			referencedInList(node.List, nested, keys)
+			referencedInList(node.ElseList, nested, keys)
+		case *parse.RangeNode:
+			referencedInPipe(node.Pipe, nested, keys)
+			referencedInList(node.List, true, keys)
+			referencedInList(node.ElseList, nested, keys)
+		case *parse.WithNode:
+			referencedInPipe(node.Pipe, nested, keys)
+			referencedInList(node.List, true, keys)
+			referencedInList(node.ElseList, nested, keys)
+		}
+	}
+}
+
+func referencedInPipe(pipe *parse.PipeNode, nested bool, keys map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, command := range pipe.Cmds {
+		for _, arg := range command.Args {
+			referencedInNode(arg, nested, keys)
+		}
+	}
+}
+
+func referencedInNode(node parse.Node, nested bool, keys map[string]bool) {
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		if !nested {
+			keys[strings.Join(node.Ident, ".")] = true
+		}
+	case *parse.VariableNode:
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			keys[strings.Join(node.Ident[1:], ".")] = true
+		}
+	case *parse.ChainNode:
+		referencedInNode(node.Node, nested, keys)
+	case *parse.PipeNode:
+		referencedInPipe(node, nested, keys)
+	}
+}
+
+// builtinFunctions are the text/template predefined functions
+var builtinFunctions = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// UnknownFunctions parses the template, without executing it, and returns the sorted names
+// of the functions it calls that are neither in the functions nor the text/template predefined ones
+func UnknownFunctions(tmpl string, functions template.FuncMap) ([]string, error) {
+	trees, err := parseTrees("functions", tmpl, "", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "can't parse the template")
+	}
+	return unknownInTrees(trees, functions), nil
+}
+
+// parseTrees parses the template and its defined templates without checking the functions
+func parseTrees(name, tmpl, leftDelim, rightDelim string) (map[string]*parse.Tree, error) {
+	trees := map[string]*parse.Tree{}
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	_, err := tree.Parse(tmpl, leftDelim, rightDelim, trees)
+	if err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// unknownInTrees returns the sorted names of the functions called in the trees
+// that are neither in the functions nor the text/template predefined ones
+func unknownInTrees(trees map[string]*parse.Tree, functions template.FuncMap) []string {
+	called := map[string]bool{}
+	for _, t := range trees {
+		calledInNode(t.Root, called)
+	}
+	for _, name := range builtinFunctions {
+		delete(called, name)
+	}
+
+	var unknown []string
+	for name := range called {
+		if _, ok := functions[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// calledInNode collects the names of the functions called in the node and its children
+func calledInNode(node parse.Node, called map[string]bool) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			calledInNode(child, called)
+		}
+	case *parse.ActionNode:
+		calledInNode(node.Pipe, called)
+	case *parse.TemplateNode:
+		calledInNode(node.Pipe, called)
+	case *parse.IfNode:
+		calledInBranch(&node.BranchNode, called)
+	case *parse.RangeNode:
+		calledInBranch(&node.BranchNode, called)
+	case *parse.WithNode:
+		calledInBranch(&node.BranchNode, called)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, command := range node.Cmds {
+			calledInNode(command, called)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			calledInNode(arg, called)
+		}
+	case *parse.IdentifierNode:
+		called[node.Ident] = true
+	case *parse.ChainNode:
+		calledInNode(node.Node, called)
+	}
+}
+
+func calledInBranch(branch *parse.BranchNode, called map[string]bool) {
+	calledInNode(branch.Pipe, called)
+	calledInNode(branch.List, called)
+	calledInNode(branch.ElseList, called)
+}
@@ -0,0 +1,49 @@
+package renderer
+
+import (
+	"reflect"
+	"sync"
+	"text/template"
+
+	"github.com/VirtusLab/go-extended/pkg/renderer/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	registeredFuncsLock sync.RWMutex
+	registeredFuncs     = template.FuncMap{}
+)
+
+// RegisterFunc registers a named template function, usually called from an init function,
+// the registered functions are available in RenderTemplate, RenderPartial and with WithRegisteredFunctions,
+// it panics if the name is already registered or the value is not a function
+func RegisterFunc(name string, fn interface{}) {
+	if fn == nil || reflect.TypeOf(fn).Kind() != reflect.Func {
+		logrus.Panicf("can't register template function '%s', it has type: '%s'", name, reflect.TypeOf(fn))
+	}
+
+	registeredFuncsLock.Lock()
+	defer registeredFuncsLock.Unlock()
+
+	if _, ok := registeredFuncs[name]; ok {
+		logrus.Panicf("template function '%s' is already registered", name)
+	}
+	registeredFuncs[name] = fn
+}
+
+// RegisteredFuncs returns a copy of the template functions registered with RegisterFunc
+func RegisteredFuncs() template.FuncMap {
+	registeredFuncsLock.RLock()
+	defer registeredFuncsLock.RUnlock()
+
+	funcs := make(template.FuncMap, len(registeredFuncs))
+	for name, fn := range registeredFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// WithRegisteredFunctions mutates Renderer configuration by merging the template functions registered with RegisterFunc
+func WithRegisteredFunctions() func(*config.Config) {
+	return WithMoreFunctions(RegisteredFuncs())
+}
@@ -0,0 +1,44 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	RegisterFunc("shout", func(s string) string { return strings.ToUpper(s) + "!" })
+}
+
+func TestRegisterFunc(t *testing.T) {
+	Run(t, Test{
+		name: "registered function",
+		f: func(tt Test) {
+			result, err := RenderTemplate(`{{ .name | shout }}`, parameters.Parameters{"name": "hello"})
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "HELLO!", result, tt.name)
+			assert.Contains(t, RegisteredFuncs(), "shout", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "duplicate registration",
+		f: func(tt Test) {
+			assert.Panics(t, func() {
+				RegisterFunc("shout", strings.ToLower)
+			}, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "not a function",
+		f: func(tt Test) {
+			assert.Panics(t, func() {
+				RegisterFunc("notAFunction", "value")
+			}, tt.name)
+		},
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -26,12 +27,17 @@ type Renderer interface {
 	Clone(configurators ...func(*config.Config)) Renderer
 	FileRender(inputPath, outputPath string) error
 	DirRender(inputDir, outputDir string) error
+	DirRenderWith(inputDir, outputDir string, options ...DirOption) error
 	NestedRender(args ...interface{}) (string, error)
 	ReadFile(file string) (string, error)
 }
 
 type renderer struct {
 	base.Renderer
+	// depth is the nesting of the template files rendered by the 'render' template function
+	depth int
+	// checkFunctions makes the renderer report all the unknown functions at once, see NewWithFunctionCheck
+	checkFunctions bool
 }
 
 // New creates a new renderer with the specified parameters and zero or more options
@@ -44,25 +50,37 @@ func New(configurators ...func(*config.Config)) Renderer {
 			"render":    r.NestedRender,
 			"readFile":  r.ReadFile,
 			"writeFile": r.WriteFile,
+			"flatten":   r.Flatten,
 		}),
 	)
 	return r
 }
 
+// NewWithFunctionCheck creates a new renderer like New, but it reports all the unknown functions
+// called by the template (if any) before executing it, instead of only the first one
+func NewWithFunctionCheck(configurators ...func(*config.Config)) Renderer {
+	r := New(configurators...).(*renderer)
+	r.checkFunctions = true
+	return r
+}
+
 // WithParameters mutates Renderer configuration by replacing all template parameters
 func WithParameters(parameters map[string]interface{}) func(*config.Config) {
 	return base.WithParameters(parameters)
 }
 
-// WithMoreParameters mutates Renderer configuration by merging the given template parameters
+// WithMoreParameters mutates Renderer configuration by merging the given template parameters (see parameters.Merge),
+// if the merge fails the error is logged and the parameters are cleared, so the renderer fails the validation
 func WithMoreParameters(extraParams ...map[string]interface{}) func(*config.Config) {
 	return func(c *config.Config) {
-		var err error
 		for _, extra := range extraParams {
-			c.Parameters, err = parameters.Merge(c.Parameters, extra)
-		}
-		if err != nil {
-			logrus.Panicf("unexpected problem merging extra functions")
+			merged, err := parameters.Merge(c.Parameters, extra)
+			if err != nil {
+				logrus.Errorf("Can't merge the extra parameters: %v", err)
+				c.Parameters = nil
+				return
+			}
+			c.Parameters = merged
 		}
 	}
 }
@@ -123,53 +141,334 @@ func MergeFunctions(dst *template.FuncMap, src template.FuncMap) error {
 	return nil
 }
 
+// Render is a simple rendering function, see also NamedRender
+func (r *renderer) Render(rawTemplate string) (string, error) {
+	return r.NamedRender("nameless", rawTemplate)
+}
+
+// NamedRender is the main rendering function, it registers the inline templates
+// defined under the parameters.TemplatesKey (name to template text) before executing the template,
+// so the main template can invoke them with e.g. '{{ template "name" . }}'.
+// An inline template with the same name as a template defined in the main template
+// (including the main template itself) is an error, the names are checked in sorted order
+func (r *renderer) NamedRender(templateName, rawTemplate string) (string, error) {
+	t, err := r.prepare(templateName, rawTemplate)
+	if err != nil {
+		return "", err
+	}
+	return r.Execute(t)
+}
+
+// prepare validates the configuration and parses the template with the inline templates
+func (r *renderer) prepare(templateName, rawTemplate string) (*template.Template, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var t *template.Template
+	if r.checkFunctions {
+		t, err = r.parseChecked(templateName, rawTemplate)
+	} else {
+		t, err = r.Parse(templateName, rawTemplate, r.Configuration().ExtraFunctions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = r.addInlineTemplates(t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseChecked parses the template like Parse, but returns an error listing all the functions
+// called by the template and not configured, so the whole template/functions mismatch is reported at once,
+// the parsed trees are reused for the template
+func (r *renderer) parseChecked(templateName, rawTemplate string) (*template.Template, error) {
+	c := r.Configuration()
+	trees, err := parseTrees(templateName, rawTemplate, c.LeftDelim, c.RightDelim)
+	if err != nil {
+		return nil, err
+	}
+	unknown := unknownInTrees(trees, c.ExtraFunctions)
+	if len(unknown) > 0 {
+		return nil, errors.Errorf("unknown template functions: '%s'", strings.Join(unknown, "', '"))
+	}
+
+	t := template.New(templateName).
+		Delims(c.LeftDelim, c.RightDelim).
+		Funcs(c.ExtraFunctions).
+		Option(c.Options...)
+	for name, tree := range trees {
+		_, err = t.AddParseTree(name, tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (r *renderer) addInlineTemplates(t *template.Template) error {
+	value, ok := r.Configuration().Parameters[parameters.TemplatesKey]
+	if !ok {
+		return nil
+	}
+	var inline map[string]interface{}
+	switch value := value.(type) {
+	case parameters.Parameters:
+		inline = value
+	case map[string]interface{}:
+		inline = value
+	default:
+		return errors.Errorf("expected '%s' to be a map, got: '%T'", parameters.TemplatesKey, value)
+	}
+
+	names := make([]string, 0, len(inline))
+	for name := range inline {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		text, ok := inline[name].(string)
+		if !ok {
+			return errors.Errorf("expected the inline template '%s' to be a 'string', got: '%T'", name, inline[name])
+		}
+		if t.Lookup(name) != nil {
+			return errors.Errorf("inline template '%s' conflicts with a template defined in '%s'", name, t.Name())
+		}
+		logrus.Debugf("Registering inline template: '%s'", name)
+		_, err := t.New(name).Parse(text)
+		if err != nil {
+			return errors.Wrapf(err, "can't parse the inline template '%s'", name)
+		}
+	}
+	return nil
+}
+
 // TODO parametrize
 var defaultTemplateExtensions = []string{".tpl", ".tmpl"}
 
-// DirRender is used to render files by directory, see also FileRender
-// TODO break up to multiple small functions
+// DirOption configures DirRenderWith
+type DirOption func(*dirConfig)
+
+type dirConfig struct {
+	continueOnError bool
+	allOrNothing    bool
+}
+
+// WithContinueOnError makes DirRenderWith render all the files, instead of stopping at the first failure,
+// the files rendered successfully are written and a DirError with all the failures is returned
+func WithContinueOnError() DirOption {
+	return func(c *dirConfig) {
+		c.continueOnError = true
+	}
+}
+
+// WithAllOrNothing makes DirRenderWith write the files only if all of them were rendered successfully,
+// use with WithContinueOnError to get all the failures
+func WithAllOrNothing() DirOption {
+	return func(c *dirConfig) {
+		c.allOrNothing = true
+	}
+}
+
+// FileError is a failure to render a single file in DirRenderWith
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// DirError aggregates the failures of DirRenderWith, see WithContinueOnError
+type DirError []FileError
+
+func (e DirError) Error() string {
+	failures := make([]string, len(e))
+	for i, failure := range e {
+		failures[i] = fmt.Sprintf("'%s': %v", failure.Path, failure.Err)
+	}
+	return fmt.Sprintf("can't render %d file(s): %s", len(e), strings.Join(failures, "; "))
+}
+
+// dirJob is a single file to render by DirRender
+type dirJob struct {
+	inputPath  string
+	outputDir  string
+	outputPath string
+	result     string
+}
+
+// DirRender is used to render files by directory, see also FileRender and DirRenderWith
 func (r *renderer) DirRender(inputDir, outputDir string) error {
+	return r.DirRenderWith(inputDir, outputDir)
+}
+
+// DirRenderWith is used to render files by directory with the options, e.g. WithContinueOnError
+func (r *renderer) DirRenderWith(inputDir, outputDir string, options ...DirOption) error {
 	logrus.Infof("Directory mode selected: '%s' -> '%s'", inputDir, outputDir)
 
+	c := &dirConfig{}
+	for _, option := range options {
+		option(c)
+	}
+
+	jobs, err := r.dirJobs(inputDir, outputDir)
+	if err != nil {
+		return err
+	}
+
+	var failures DirError
+	var rendered []dirJob
+	for _, job := range jobs {
+		logrus.Debugf("Processing '%s'", job.inputPath)
+
+		job.result, err = r.renderFile(job.inputPath, job.outputPath)
+		if err != nil {
+			if !c.continueOnError {
+				return errors.Wrap(err, "can't render a file")
+			}
+			logrus.Errorf("Can't render '%s': %v", job.inputPath, err)
+			failures = append(failures, FileError{Path: job.inputPath, Err: err})
+			continue
+		}
+
+		if c.allOrNothing {
+			rendered = append(rendered, job)
+			continue
+		}
+		err = writeDirJob(job)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 && c.allOrNothing {
+		logrus.Infof("Nothing was written, %d file(s) failed to render", len(failures))
+		return failures
+	}
+	for _, job := range rendered {
+		err = writeDirJob(job)
+		if err != nil {
+			return err
+		}
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// RenderDirValidate renders every file of the directory tree like DirRender, with the parameters and the template
+// functions of RenderTemplate, but only in memory, nothing is written, e.g. for a CI validation step,
+// the failures of all the files are returned as a DirError
+func RenderDirValidate(srcDir string, params parameters.Parameters) error {
+	logrus.Infof("Validating the directory: '%s'", srcDir)
+	r := New(defaultConfigurators(params)...).(*renderer)
+
+	jobs, err := r.dirJobs(srcDir, "")
+	if err != nil {
+		return err
+	}
+
+	var failures DirError
+	for _, job := range jobs {
+		logrus.Debugf("Validating '%s'", job.inputPath)
+		input, err := files.ReadInput(job.inputPath)
+		if err == nil {
+			_, err = r.NamedRender(job.inputPath, string(input))
+		}
+		if err != nil {
+			logrus.Errorf("Can't render '%s': %v", job.inputPath, err)
+			failures = append(failures, FileError{Path: job.inputPath, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// dirJobs lists the files to render with their target paths, the relative directory and file names
+// are rendered as templates too, e.g. '{{ .service }}.conf.tmpl' is written as 'web.conf'
+func (r *renderer) dirJobs(inputDir, outputDir string) ([]dirJob, error) {
 	fileEntries, err := dirTree(inputDir)
 	if err != nil {
-		return errors.Wrapf(err, "can't scan the directory tree: '%s'", inputDir)
+		return nil, errors.Wrapf(err, "can't scan the directory tree: '%s'", inputDir)
 	}
 
+	var jobs []dirJob
 	for _, file := range fileEntries {
-		logrus.Debugf("Processing '%s'", path.Join(file.path, file.name))
-
 		target := trimExtension(file, defaultTemplateExtensions)
 
 		rel, err := filepath.Rel(inputDir, file.path)
 		if err != nil {
-			return errors.Wrapf(err, "can't get a relative path for: '%s'", file.path)
+			return nil, errors.Wrapf(err, "can't get a relative path for: '%s'", file.path)
 		}
-
-		target.path = path.Join(outputDir, rel)
-
-		_, err = os.Stat(target.path)
-		if os.IsNotExist(err) {
-			err := os.MkdirAll(target.path, os.ModePerm)
+		components := strings.Split(filepath.ToSlash(rel), "/")
+		for i := range components {
+			components[i], err = r.renderPathComponent(components[i])
 			if err != nil {
-				return errors.Wrapf(err, "can't create the target directory: '%s'", target.path)
+				return nil, errors.Wrapf(err, "can't render the target path for: '%s'", path.Join(file.path, file.name))
 			}
-			logrus.Infof("Target directory was created: '%s'", target.path)
-		} else if err != nil {
-			return errors.Wrapf(err, "can't get file information for '%s'", target.path)
+		}
+		target.name, err = r.renderPathComponent(target.name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't render the target path for: '%s'", path.Join(file.path, file.name))
 		}
 
-		err = r.FileRender(path.Join(file.path, file.name), path.Join(target.path, target.name))
+		target.path = path.Join(append([]string{outputDir}, components...)...)
+		jobs = append(jobs, dirJob{
+			inputPath:  path.Join(file.path, file.name),
+			outputDir:  target.path,
+			outputPath: path.Join(target.path, target.name),
+		})
+	}
+	return jobs, nil
+}
+
+// renderPathComponent renders a single directory or file name, the result must be a single non-empty
+// path component, so a parameter can't move the file outside of the target directory
+func (r *renderer) renderPathComponent(component string) (string, error) {
+	if component == "." {
+		return component, nil
+	}
+	rendered, err := r.Render(component)
+	if err != nil {
+		return "", err
+	}
+	if rendered == "" || rendered == "." || rendered == ".." || strings.ContainsAny(rendered, `/\`) {
+		return "", errors.Errorf("invalid path component '%s' rendered from: '%s'", rendered, component)
+	}
+	return rendered, nil
+}
+
+// writeDirJob writes the rendered file, creating the target directory if needed
+func writeDirJob(job dirJob) error {
+	_, err := os.Stat(job.outputDir)
+	if os.IsNotExist(err) {
+		err := os.MkdirAll(job.outputDir, os.ModePerm)
 		if err != nil {
-			return errors.Wrap(err, "can't render a file")
+			return errors.Wrapf(err, "can't create the target directory: '%s'", job.outputDir)
 		}
+		logrus.Infof("Target directory was created: '%s'", job.outputDir)
+	} else if err != nil {
+		return errors.Wrapf(err, "can't get file information for '%s'", job.outputDir)
 	}
 
-	return nil
+	return writeRendered(job.outputPath, job.result)
 }
 
 // FileRender is used to render files by path, see also DirRender
 func (r *renderer) FileRender(inputPath, outputPath string) error {
+	result, err := r.renderFile(inputPath, outputPath)
+	if err != nil {
+		return err
+	}
+	return writeRendered(outputPath, result)
+}
+
+// renderFile renders the template file, or the stdin if the input path is empty
+func (r *renderer) renderFile(inputPath, outputPath string) (string, error) {
 	inputName := inputPath
 	outputName := outputPath
 	if inputPath == "" {
@@ -183,7 +482,7 @@ func (r *renderer) FileRender(inputPath, outputPath string) error {
 	input, err := files.ReadInput(inputPath)
 	if err != nil {
 		logrus.Debugf("Can't open the template: %v", err)
-		return err
+		return "", err
 	}
 
 	var templateName string
@@ -197,23 +496,27 @@ func (r *renderer) FileRender(inputPath, outputPath string) error {
 	logrus.Debugf("%s: \n%s", inputName, inputString)
 	result, err := r.NamedRender(templateName, inputString)
 	if err != nil {
-		return err
+		return "", err
 	}
 	logrus.Debugf("%s: \n%s", outputName, result)
+	return result, nil
+}
 
-	err = files.WriteOutput(outputPath, []byte(result), 0644)
+// writeRendered writes the result to the file, or the stdout if the path is empty
+func writeRendered(outputPath, result string) error {
+	err := files.WriteOutput(outputPath, []byte(result), 0644)
 	if err != nil {
 		logrus.Debugf("Can't save the rendered file: %v", err)
 		return err
 	}
-
 	return nil
 }
 
 // Clone returns a new copy of the renderer modified with the optional configurators
 func (r *renderer) Clone(configurators ...func(*config.Config)) Renderer {
 	clone := &renderer{
-		Renderer: base.NewWithConfig(r.Configuration()),
+		Renderer:       base.NewWithConfig(r.Configuration()),
+		checkFunctions: r.checkFunctions,
 	}
 	clone.Reconfigure(configurators...)
 	logrus.Debugf("cloned renderer: %+v", clone.String())
@@ -228,13 +531,23 @@ func (r *renderer) String() string {
 // to the standard (text/template) ones
 func ExtraFunctions() template.FuncMap {
 	return template.FuncMap{
-		"n":        N,
-		"toYaml":   ToYAML,
-		"fromYaml": FromYAML,
-		"fromJson": FromJSON,
-		"jsonPath": JSONPath,
-		"ungzip":   Ungzip,
-		"gzip":     Gzip,
+		"n":            N,
+		"toYaml":       ToYAML,
+		"fromYaml":     FromYAML,
+		"fromJson":     FromJSON,
+		"jsonPath":     JSONPath,
+		"ungzip":       Ungzip,
+		"gzip":         Gzip,
+		"required":     Required,
+		"stableChoice": StableChoice,
+		"numEq":        NumEq,
+		"numLt":        NumLt,
+		"numGt":        NumGt,
+		"isFirst":      IsFirst,
+		"isLast":       IsLast,
+		"at":           At,
+		"hasPath":      HasPath,
+		"toc":          TOC,
 	}
 }
 
@@ -1,8 +1,14 @@
 package renderer
 
 import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/VirtusLab/render/renderer/parameters"
 
@@ -224,6 +230,729 @@ func TestRenderer_Render_Pipe(t *testing.T) {
 	})
 }
 
+func TestRenderer_NamedRender_InlineTemplates(t *testing.T) {
+	Run(t, Test{
+		name: "inline templates",
+		f: func(tt Test) {
+			input := `{{ template "greeting" . }}, {{ template "farewell" .name }}`
+			expected := "hello render, bye render"
+			params := parameters.Parameters{
+				"name": "render",
+				parameters.TemplatesKey: map[string]interface{}{
+					"greeting": "hello {{ .name }}",
+					"farewell": "bye {{ . }}",
+				},
+			}
+
+			result, err := New(WithParameters(params)).NamedRender(tt.name, input)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, expected, result, tt.name)
+			assert.Equal(t, 0, CountProblems(tt.logHook))
+		},
+	})
+}
+
+func TestRenderer_NamedRender_InlineTemplatesConflict(t *testing.T) {
+	Run(t, Test{
+		name: "inline templates conflict",
+		f: func(tt Test) {
+			input := `{{ define "greeting" }}hi{{ end }}{{ template "greeting" . }}`
+			params := parameters.Parameters{
+				parameters.TemplatesKey: parameters.Parameters{
+					"greeting": "hello",
+					"other":    "other",
+				},
+			}
+
+			result, err := New(WithParameters(params)).NamedRender(tt.name, input)
+
+			assert.EqualError(t, err, "inline template 'greeting' conflicts with a template defined in 'inline templates conflict'")
+			assert.Equal(t, "", result)
+		},
+	})
+}
+
+func TestRenderPartial(t *testing.T) {
+	Run(t, Test{
+		name: "partial render",
+		f: func(tt Test) {
+			input := `known: {{ .known }}
+unknown: {{ .stage2.value | quote }}
+nested: {{ if .known }}{{ $.missing }}{{ end }}`
+			expected := `known: value
+unknown: {{ .stage2.value | quote }}
+nested: {{ $.missing }}`
+			params := parameters.Parameters{
+				"known": "value",
+			}
+
+			result, err := RenderPartial(input, params)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, expected, result, tt.name)
+
+			result, err = RenderPartial(result, parameters.Parameters{
+				"stage2":  parameters.Parameters{"value": "second"},
+				"missing": "third",
+			})
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "known: value\nunknown: \"second\"\nnested: third", result, tt.name)
+		},
+	})
+}
+
+func TestRenderPartial_DelimiterInString(t *testing.T) {
+	Run(t, Test{
+		name: "partial render with a delimiter in a string",
+		f: func(tt Test) {
+			input := `a: {{ .missing | printf "}}%v" }}
+b: {{ printf "%v{{" .missing }}
+c: {{ "}}" }}`
+			result, err := RenderPartial(input, parameters.Parameters{})
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, `a: {{ .missing | printf "}}%v" }}
+b: {{ printf "%v{{" .missing }}
+c: }}`, result, tt.name)
+		},
+	})
+}
+
+func TestRenderValue(t *testing.T) {
+	Run(t, Test{
+		name: "top level slice",
+		f: func(tt Test) {
+			data := []interface{}{
+				map[string]interface{}{"name": "web"},
+				map[string]interface{}{"name": "db"},
+			}
+
+			result, err := RenderValue(`{{ range . }}{{ .name | upper }};{{ end }}{{ len . }}`, data)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "WEB;DB;2", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "scalar",
+		f: func(tt Test) {
+			result, err := RenderValue(`value: {{ . | quote }}`, 42)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, `value: "42"`, result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "map",
+		f: func(tt Test) {
+			result, err := RenderValue(`{{ .name }}`, map[string]interface{}{"name": "render"})
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "render", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "execution error",
+		f: func(tt Test) {
+			_, err := RenderValue(`{{ .name }}`, []interface{}{1})
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't render the template with the data of type: '[]interface {}'", tt.name)
+		},
+	})
+}
+
+func TestRenderWithCleanup(t *testing.T) {
+	params := parameters.Parameters{"items": []interface{}{"a", "b"}}
+	tmpl := "items:  \n{{ range .items }}\n\n  - {{ . }}\t\n{{ end }}\n\n\nend\n"
+
+	Run(t, Test{
+		name: "cleanup",
+		f: func(tt Test) {
+			result, err := RenderWithCleanup(tmpl, params, true)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "items:\n\n  - a\n\n  - b\n\nend\n", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "no cleanup",
+		f: func(tt Test) {
+			result, err := RenderWithCleanup(tmpl, params, false)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "items:  \n\n\n  - a\t\n\n\n  - b\t\n\n\n\nend\n", result, tt.name)
+		},
+	})
+}
+
+func TestRenderBounded(t *testing.T) {
+	params := parameters.Parameters{"name": "api", "count": 1000}
+
+	Run(t, Test{
+		name: "under the limit",
+		f: func(tt Test) {
+			result, err := RenderBounded(`name: {{ .name }}`, params, 9)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "name: api", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "over the limit",
+		f: func(tt Test) {
+			_, err := RenderBounded(`{{ range until .count }}0123456789{{ end }}`, params, 95)
+
+			assert.Error(t, err, tt.name)
+			assert.Equal(t, "the rendered output exceeds the limit of 95 bytes, 90 bytes written and 10 more attempted",
+				err.Error(), tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "template error",
+		f: func(tt Test) {
+			_, err := RenderBounded(`{{ .missing }}`, params, 100)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't render the template 'bounded'", tt.name)
+		},
+	})
+}
+
+func TestRenderWithLocale(t *testing.T) {
+	params := parameters.Parameters{
+		"price":    1234567.891,
+		"count":    1500,
+		"released": time.Date(2021, time.March, 7, 10, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		locale   string
+		input    string
+		expected string
+	}{
+		{name: "comma grouping", locale: "en-US", input: `{{ .price | numberFormat 2 }} {{ .count | numberFormat 0 }}`, expected: "1,234,567.89 1,500"},
+		{name: "period grouping", locale: "de-DE", input: `{{ .price | numberFormat 2 }} {{ .count | numberFormat 0 }}`, expected: "1.234.567,89 1.500"},
+		{name: "english month", locale: "en", input: `{{ .released | dateLocale "Monday, 2 January 2006 15:04" }}`, expected: "Sunday, 7 March 2021 10:30"},
+		{name: "localized month", locale: "de", input: `{{ .released | dateLocale "Monday, 2. January 2006" }}`, expected: "Sonntag, 7. März 2021"},
+		{name: "localized short names", locale: "fr-FR", input: `{{ .released | dateLocale "Mon 02 Jan 06" }}`, expected: "dim. 07 mars 21"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := RenderWithLocale(tc.input, params, tc.locale)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "invalid locale",
+		f: func(tt Test) {
+			_, err := RenderWithLocale(`{{ .count }}`, params, "not a locale")
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "invalid locale: 'not a locale'", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "unsupported date locale",
+		f: func(tt Test) {
+			_, err := RenderWithLocale(`{{ .released | dateLocale "January" }}`, params, "ja")
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "dateLocale doesn't support the locale: 'ja'", tt.name)
+		},
+	})
+}
+
+func TestRenderGzip(t *testing.T) {
+	params := parameters.Parameters{"name": "api", "replicas": 2}
+
+	Run(t, Test{
+		name: "same as plain render",
+		f: func(tt Test) {
+			tmpl := `name: {{ .name | upper }}, replicas: {{ .replicas }}`
+			var buffer bytes.Buffer
+			err := RenderGzip(&buffer, tmpl, params)
+			assert.NoError(t, err, tt.name)
+
+			expected, err := RenderTemplate(tmpl, params)
+			assert.NoError(t, err, tt.name)
+			result, err := Ungzip(buffer.Bytes())
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, expected, result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "render error",
+		f: func(tt Test) {
+			var buffer bytes.Buffer
+			err := RenderGzip(&buffer, `{{ fail "boom" }}`, params)
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "boom", tt.name)
+
+			result, err := Ungzip(buffer.Bytes())
+			assert.NoError(t, err, tt.name)
+			assert.Empty(t, result, tt.name)
+		},
+	})
+}
+
+func TestRenderTwoPhase(t *testing.T) {
+	base := parameters.Parameters{"name": "api", "replicas": 2}
+
+	Run(t, Test{
+		name: "derived key",
+		f: func(tt Test) {
+			paramsTmpl := `fullName: {{ .name }}-service
+maxReplicas: {{ mul .replicas 2 }}`
+			mainTmpl := `{{ .fullName }}: {{ .replicas }}..{{ .maxReplicas }}`
+
+			result, err := RenderTwoPhase(paramsTmpl, mainTmpl, base)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "api-service: 2..4", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "invalid YAML",
+		f: func(tt Test) {
+			_, err := RenderTwoPhase(`{{ .name }}: [unclosed`, `{{ .name }}`, base)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "parameters phase: the parameters template output is not a valid YAML", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "main phase error",
+		f: func(tt Test) {
+			_, err := RenderTwoPhase(`fullName: {{ .name }}`, `{{ .missing }}`, base)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "main phase: can't render the main template", tt.name)
+		},
+	})
+}
+
+func TestRenderer_NamedRender_Flatten(t *testing.T) {
+	params := parameters.Parameters{
+		"servers": parameters.Parameters{
+			"web": parameters.Parameters{"port": 80},
+			"db":  parameters.Parameters{"port": 5432, "hosts": []interface{}{"a", "b"}},
+		},
+		"serversCount": 2,
+	}
+
+	Run(t, Test{
+		name: "flatten with prefix",
+		f: func(tt Test) {
+			input := `{{ range flatten "servers" }}{{ .Key }}={{ .Value }};{{ end }}`
+			expected := "servers.db.hosts[0]=a;servers.db.hosts[1]=b;servers.db.port=5432;servers.web.port=80;"
+
+			result, err := New(WithParameters(params)).NamedRender(tt.name, input)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, expected, result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "flatten without matches",
+		f: func(tt Test) {
+			input := `{{ range flatten "clients" }}{{ .Key }}={{ .Value }};{{ end }}`
+
+			result, err := New(WithParameters(params)).NamedRender(tt.name, input)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "", result, tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_Required(t *testing.T) {
+	params := parameters.Parameters{
+		"db": parameters.Parameters{"host": "localhost", "user": ""},
+	}
+
+	Run(t, Test{
+		name: "present value",
+		f: func(tt Test) {
+			result, err := New(
+				WithParameters(params),
+				WithExtraFunctions(),
+			).Render(`host: {{ required "db.host is required" .db.host }}`)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "host: localhost", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "missing value",
+		f: func(tt Test) {
+			_, err := New(
+				WithOptions("missingkey=zero"),
+				WithParameters(params),
+				WithExtraFunctions(),
+			).Render(`port: {{ required "db.port is required" .db.port }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "db.port is required", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "empty value",
+		f: func(tt Test) {
+			_, err := New(
+				WithParameters(params),
+				WithExtraFunctions(),
+			).Render(`user: {{ .db.user | required "db.user is required" }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "db.user is required", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_StableChoice(t *testing.T) {
+	r := New(WithSprigFunctions(), WithExtraFunctions())
+
+	Run(t, Test{
+		name: "same seed",
+		f: func(tt Test) {
+			input := `{{ stableChoice "api" (list "a" "b" "c" "d") }}`
+			first, err := r.Render(input)
+			assert.NoError(t, err, tt.name)
+
+			for i := 0; i < 5; i++ {
+				again, err := r.Render(input)
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, first, again, tt.name)
+			}
+		},
+	})
+
+	Run(t, Test{
+		name: "different seeds",
+		f: func(tt Test) {
+			list := []interface{}{"a", "b", "c", "d"}
+			chosen := map[interface{}]int{}
+			for i := 0; i < 100; i++ {
+				choice, err := StableChoice(fmt.Sprintf("service-%d", i), list)
+				assert.NoError(t, err, tt.name)
+				chosen[choice]++
+			}
+			assert.Len(t, chosen, len(list), tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "empty list",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ stableChoice "api" list }}`)
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "stableChoice expects a non-empty list", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_NumericComparisons(t *testing.T) {
+	params := parameters.Parameters{
+		"replicas": 1,
+		"fromJSON": 1.0,
+		"limit":    2.5,
+		"name":     "render",
+	}
+	r := New(WithParameters(params), WithExtraFunctions())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "eq across types", input: `{{ numEq .replicas .fromJSON }} {{ numEq .replicas .limit }}`, expected: "true false"},
+		{name: "lt across types", input: `{{ numLt .replicas .limit }} {{ numLt .limit .replicas }}`, expected: "true false"},
+		{name: "gt across types", input: `{{ numGt .limit .replicas }} {{ numGt .replicas .fromJSON }}`, expected: "true false"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := r.Render(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "not a number",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ numEq .replicas .name }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "numEq expects numbers, got type: 'string'", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_IsFirstIsLast(t *testing.T) {
+	params := parameters.Parameters{
+		"items": []interface{}{"a", "b", "c"},
+		"empty": []interface{}{},
+	}
+	r := New(WithParameters(params), WithSprigFunctions(), WithExtraFunctions())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "comma joined with isLast",
+			input:    `{{ range $i, $e := .items }}{{ $e }}{{ if not (isLast $i $.items) }}, {{ end }}{{ end }}`,
+			expected: "a, b, c",
+		},
+		{
+			name:     "comma joined with isFirst",
+			input:    `{{ range $i, $e := .items }}{{ if not (isFirst $i $.items) }}, {{ end }}{{ $e }}{{ end }}`,
+			expected: "a, b, c",
+		},
+		{
+			name:     "empty slice",
+			input:    `[{{ range $i, $e := .empty }}{{ $e }}{{ if not (isLast $i $.empty) }}, {{ end }}{{ end }}]`,
+			expected: "[]",
+		},
+		{name: "index out of range", input: `{{ isFirst 1 .items }} {{ isLast 0 .empty }}`, expected: "false false"},
+		{name: "sprig elements", input: `{{ first .items }} {{ last .items }} {{ last .empty }}`, expected: "a c <no value>"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := r.Render(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "not a collection",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ isLast 0 1 }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "isLast expects a collection, got type: 'int'", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "not a number index",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ isFirst "a" .items }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "isFirst expects a number index, got type: 'string'", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_Lists(t *testing.T) {
+	params := parameters.Parameters{
+		"tags":  []interface{}{"web", "db", "web", "cache", "db"},
+		"mixed": []interface{}{1, "two", 3.5, true, int64(5)},
+		"empty": []interface{}{},
+	}
+	r := New(WithParameters(params), WithSprigFunctions(), WithExtraFunctions())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "uniq with duplicates", input: `{{ uniq .tags }}`, expected: "[web db cache]"},
+		{name: "sortAlpha", input: `{{ sortAlpha .tags }}`, expected: "[cache db db web web]"},
+		{name: "reverse", input: `{{ reverse .mixed }}`, expected: "[5 true 3.5 two 1]"},
+		{name: "first", input: `{{ first .tags }}`, expected: "web"},
+		{name: "first of empty", input: `{{ first .empty }}`, expected: "<no value>"},
+		{name: "first piped", input: `{{ .tags | reverse | first }}`, expected: "db"},
+		{name: "rest", input: `{{ rest .tags }}`, expected: "[db web cache db]"},
+		{name: "join mixed scalars", input: `{{ join ", " .mixed }}`, expected: "1, two, 3.5, true, 5"},
+		{name: "composed", input: `{{ .tags | uniq | sortAlpha | join "," }}`, expected: "cache,db,web"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := r.Render(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "not shadowed by the extra functions",
+		f: func(tt Test) {
+			extra := ExtraFunctions()
+			for _, name := range []string{"uniq", "sortAlpha", "reverse", "first", "rest", "join"} {
+				_, ok := extra[name]
+				assert.False(t, ok, "%s: %s", tt.name, name)
+			}
+		},
+	})
+}
+
+func TestRenderer_Render_At(t *testing.T) {
+	params := parameters.Parameters{
+		"list":   []interface{}{"a", "b"},
+		"labels": parameters.Parameters{"team": "core"},
+		"none":   nil,
+	}
+	r := New(WithParameters(params), WithExtraFunctions())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "in range", input: `{{ at .list 1 "default" }}`, expected: "b"},
+		{name: "out of range", input: `{{ at .list 3 "default" }} {{ at .list -1 "default" }}`, expected: "default default"},
+		{name: "map key", input: `{{ at .labels "team" "none" }} {{ at .labels "owner" "none" }}`, expected: "core none"},
+		{name: "nil collection", input: `{{ at .none 0 "default" }}`, expected: "default"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := r.Render(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "invalid index",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ at .list "first" "default" }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "at expects a number index for a slice, got type: 'string'", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_HasPath(t *testing.T) {
+	params := parameters.Parameters{
+		"db":    parameters.Parameters{"host": "localhost", "password": "", "user": nil},
+		"hosts": []interface{}{"a", "b"},
+	}
+	r := New(WithParameters(params), WithSprigFunctions(), WithExtraFunctions())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "existing key", input: `{{ hasPath . "db.host" }} {{ hasPath .db "host" }} {{ hasPath . "hosts.1" }}`, expected: "true true true"},
+		{name: "missing key", input: `{{ hasPath . "db.port" }} {{ hasPath . "cache.host" }} {{ hasPath . "hosts.2" }}`, expected: "false false false"},
+		{name: "existing empty key", input: `{{ hasPath . "db.password" }} {{ hasPath . "db.user" }}`, expected: "true true"},
+		{name: "block", input: `{{ if hasPath . "db.password" }}password: '{{ .db.password }}'{{ end }}`, expected: "password: ''"},
+		{name: "sprig has", input: `{{ has "b" .hosts }} {{ has "c" .hosts }}`, expected: "true false"},
+	}
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := r.Render(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "not a map",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ hasPath .hosts "0" }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "hasPath expects a map, got type: '[]interface {}'", tt.name)
+		},
+	})
+}
+
+func TestRenderer_Render_TOC(t *testing.T) {
+	Run(t, Test{
+		name: "nested tree",
+		f: func(tt Test) {
+			params := parameters.Parameters{
+				"debug": true,
+				"db": parameters.Parameters{
+					"port":        5432,
+					"credentials": map[string]interface{}{"user": "admin", "password": "secret"},
+				},
+				"hosts": []interface{}{parameters.Parameters{"name": "a"}},
+			}
+			result, err := New(WithParameters(params), WithExtraFunctions()).Render(`{{ toc . }}`)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, `- db
+  - credentials
+    - password
+    - user
+  - port
+- debug
+- hosts
+`, result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "empty tree",
+		f: func(tt Test) {
+			result, err := New(WithParameters(parameters.Parameters{}), WithExtraFunctions()).Render(`{{ toc . }}`)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "not a map",
+		f: func(tt Test) {
+			_, err := New(WithParameters(parameters.Parameters{"list": []interface{}{}}), WithExtraFunctions()).Render(`{{ toc .list }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "toc expects a map, got type: '[]interface {}'", tt.name)
+		},
+	})
+}
+
 func TestRenderer_FuzzFalsePositive1(t *testing.T) {
 	t.Run("go-fuzz crash 1", func(t *testing.T) {
 		input := "{{range $,$ =.}}{{end}}"
@@ -247,12 +976,508 @@ func TestRenderer_Render_Validate_Default(t *testing.T) {
 	})
 }
 
+func TestRenderer_WithMoreParameters(t *testing.T) {
+	Run(t, Test{
+		name: "more parameters",
+		f: func(tt Test) {
+			r := New(
+				WithParameters(parameters.Parameters{"db": parameters.Parameters{"host": "localhost"}, "name": "app"}),
+				WithMoreParameters(map[string]interface{}{"db": "postgres://remote"}, map[string]interface{}{"name": "web"}),
+			)
+			result, err := r.Render("{{ .db }} {{ .name }}")
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "postgres://remote web", result, tt.name)
+			assert.Equal(t, 0, CountProblems(tt.logHook))
+		},
+	})
+}
+
 type Test struct {
 	name    string
 	f       func(tt Test)
 	logHook *test.Hook
 }
 
+func TestRenderer_DirRender_ContinueOnError(t *testing.T) {
+	setup := func(t *testing.T) (string, string) {
+		inputDir := t.TempDir()
+		templates := map[string]string{
+			"good.yaml.tmpl":     "name: {{ .name }}",
+			"bad.yaml.tmpl":      "name: {{ .name ",
+			"sub/worse.yaml.tpl": "name: {{ .missing }}",
+		}
+		for name, content := range templates {
+			file := filepath.Join(inputDir, name)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+			assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		}
+		return inputDir, t.TempDir()
+	}
+	r := New(
+		WithOptions("missingkey=error"),
+		WithParameters(parameters.Parameters{"name": "render"}),
+	)
+
+	Run(t, Test{
+		name: "stops at the first failure",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t)
+
+			err := r.DirRender(inputDir, outputDir)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't render a file", tt.name)
+			assert.NotContains(t, err.Error(), "worse.yaml.tpl", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "aggregated failures",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t)
+
+			err := r.DirRenderWith(inputDir, outputDir, WithContinueOnError())
+
+			dirErr, ok := err.(DirError)
+			assert.True(t, ok, tt.name)
+			assert.Len(t, dirErr, 2, tt.name)
+			assert.Contains(t, err.Error(), "can't render 2 file(s)", tt.name)
+			assert.Contains(t, err.Error(), filepath.Join(inputDir, "bad.yaml.tmpl"), tt.name)
+			assert.Contains(t, err.Error(), filepath.Join(inputDir, "sub/worse.yaml.tpl"), tt.name)
+
+			good, err := os.ReadFile(filepath.Join(outputDir, "good.yaml"))
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "name: render", string(good), tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "all or nothing",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t)
+
+			err := r.DirRenderWith(inputDir, outputDir, WithContinueOnError(), WithAllOrNothing())
+
+			assert.Len(t, err, 2, tt.name)
+			_, err = os.Stat(filepath.Join(outputDir, "good.yaml"))
+			assert.True(t, os.IsNotExist(err), tt.name)
+		},
+	})
+}
+
+func TestRenderer_DirRender_TemplatedNames(t *testing.T) {
+	setup := func(t *testing.T, templates map[string]string) (string, string) {
+		inputDir := t.TempDir()
+		for name, content := range templates {
+			file := filepath.Join(inputDir, name)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+			assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		}
+		return inputDir, t.TempDir()
+	}
+	r := New(WithParameters(parameters.Parameters{
+		"service": "web",
+		"env":     "prod",
+		"escape":  "../etc",
+	}))
+
+	Run(t, Test{
+		name: "templated file and directory names",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t, map[string]string{
+				"{{.service}}.conf.tmpl":     "service: {{ .service }}",
+				"{{.env}}/{{.service}}.yaml": "env: {{ .env }}",
+				"static/plain.txt":           "plain",
+			})
+
+			err := r.DirRender(inputDir, outputDir)
+
+			assert.NoError(t, err, tt.name)
+			for file, expected := range map[string]string{
+				"web.conf":         "service: web",
+				"prod/web.yaml":    "env: prod",
+				"static/plain.txt": "plain",
+			} {
+				content, err := os.ReadFile(filepath.Join(outputDir, file))
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, expected, string(content), tt.name)
+			}
+		},
+	})
+
+	Run(t, Test{
+		name: "path separator injection",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t, map[string]string{
+				"{{.escape}}.conf.tmpl": "service: {{ .service }}",
+			})
+
+			err := r.DirRender(inputDir, outputDir)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "invalid path component '../etc.conf' rendered from: '{{.escape}}.conf'", tt.name)
+			entries, _ := os.ReadDir(outputDir)
+			assert.Empty(t, entries, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "empty name",
+		f: func(tt Test) {
+			inputDir, outputDir := setup(t, map[string]string{
+				"{{ if false }}x{{ end }}/a.yaml": "a",
+			})
+
+			err := r.DirRender(inputDir, outputDir)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "invalid path component '' rendered from: '{{ if false }}x{{ end }}'", tt.name)
+		},
+	})
+}
+
+func TestRenderer_NestedRender_File(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"service.tmpl": `service: {{ .name }}, {{ render "db.tmpl" .db }}`,
+		"db.tmpl":      `db: {{ .host }}`,
+		"list.tmpl":    `{{ range . }}[{{ . }}]{{ end }}`,
+		"loop.tmpl":    `{{ render "loop.tmpl" . }}`,
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	params := parameters.Parameters{
+		parameters.RootKey: dir,
+		"name":             "web",
+		"db":               parameters.Parameters{"host": "localhost"},
+		"tags":             []interface{}{"a", "b"},
+	}
+	r := New(WithParameters(params), WithSprigFunctions(), WithExtraFunctions())
+
+	Run(t, Test{
+		name: "nested inclusion",
+		f: func(tt Test) {
+			result, err := r.Render(`{{ render "service.tmpl" . }}; {{ render "list.tmpl" .tags }}`)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "service: web, db: localhost; [a][b]", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "recursion limit",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ render "loop.tmpl" . }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't render the file 'loop.tmpl', the maximum render depth of 10 exceeded", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "missing file",
+		f: func(tt Test) {
+			_, err := r.Render(`{{ render "missing.tmpl" . }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't render the file 'missing.tmpl'", tt.name)
+		},
+	})
+}
+
+func TestRenderDirValidate(t *testing.T) {
+	setup := func(t *testing.T, templates map[string]string) string {
+		inputDir := t.TempDir()
+		for name, content := range templates {
+			file := filepath.Join(inputDir, name)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+			assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		}
+		return inputDir
+	}
+	params := parameters.Parameters{"name": "render", "replicas": 2}
+
+	Run(t, Test{
+		name: "one bad template",
+		f: func(tt Test) {
+			inputDir := setup(t, map[string]string{
+				"good.yaml.tmpl":    "name: {{ .name }}",
+				"sub/also.yaml.tpl": "replicas: {{ .replicas | add 1 }}",
+				"sub/bad.yaml.tmpl": "name: {{ .missing }}",
+				"plain/config.yaml": "name: plain",
+			})
+
+			err := RenderDirValidate(inputDir, params)
+
+			dirErr, ok := err.(DirError)
+			assert.True(t, ok, tt.name)
+			assert.Len(t, dirErr, 1, tt.name)
+			assert.Equal(t, filepath.Join(inputDir, "sub/bad.yaml.tmpl"), dirErr[0].Path, tt.name)
+			assert.Contains(t, err.Error(), "can't render 1 file(s)", tt.name)
+			assert.Contains(t, err.Error(), "missing", tt.name)
+
+			entries, err := os.ReadDir(inputDir)
+			assert.NoError(t, err, tt.name)
+			assert.Len(t, entries, 3, "nothing is written")
+		},
+	})
+
+	Run(t, Test{
+		name: "all good",
+		f: func(tt Test) {
+			inputDir := setup(t, map[string]string{
+				"good.yaml.tmpl":    "name: {{ .name }}",
+				"sub/also.yaml.tpl": "replicas: {{ .replicas }}",
+			})
+
+			err := RenderDirValidate(inputDir, params)
+
+			assert.NoError(t, err, tt.name)
+		},
+	})
+}
+
+func TestReferencedKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "nested fields",
+			input:    `{{ .a.b }} {{ .a.c.d }} {{ .a.b }} {{ if .enabled }}{{ .e }}{{ end }}`,
+			expected: []string{"a.b", "a.c.d", "e", "enabled"},
+		},
+		{
+			name:     "ranged field",
+			input:    `{{ range .servers }}{{ .name }}:{{ $.domain }}{{ else }}{{ .fallback }}{{ end }}`,
+			expected: []string{"domain", "fallback", "servers"},
+		},
+		{
+			name:     "pipeline expression",
+			input:    `{{ .name | default .defaults.name | upper }} {{ printf "%s" (.prefix | lower) }}`,
+			expected: []string{"defaults.name", "name", "prefix"},
+		},
+		{
+			name:     "no references",
+			input:    `plain text {{ "literal" }}`,
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		Run(t, Test{
+			name: tc.name,
+			f: func(tt Test) {
+				result, err := ReferencedKeys(tc.input)
+
+				assert.NoError(t, err, tt.name)
+				assert.Equal(t, tc.expected, result, tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "parse error",
+		f: func(tt Test) {
+			_, err := ReferencedKeys(`{{ .a `)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "can't parse the template", tt.name)
+		},
+	})
+}
+
+func TestUnknownFunctions(t *testing.T) {
+	functions := ExtraFunctions()
+
+	Run(t, Test{
+		name: "known functions",
+		f: func(tt Test) {
+			unknown, err := UnknownFunctions(`{{ if eq (len .a) 1 }}{{ .a | toYaml }}{{ end }}`, functions)
+
+			assert.NoError(t, err, tt.name)
+			assert.Empty(t, unknown, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "unknown functions",
+		f: func(tt Test) {
+			tmpl := `{{ define "x" }}{{ shout . }}{{ end }}{{ range .a }}{{ . | whisper | toYaml }}{{ end }}{{ template "x" (shout .b) }}`
+			unknown, err := UnknownFunctions(tmpl, functions)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, []string{"shout", "whisper"}, unknown, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "reported by the renderer",
+		f: func(tt Test) {
+			r := NewWithFunctionCheck(WithParameters(parameters.Parameters{"a": 1}), WithExtraFunctions())
+			_, err := r.Render(`{{ .a | whisper }} {{ shout .a }} {{ toYaml .a }}`)
+
+			assert.EqualError(t, err, "unknown template functions: 'shout', 'whisper'", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "known functions rendered by the checking renderer",
+		f: func(tt Test) {
+			r := NewWithFunctionCheck(
+				WithParameters(parameters.Parameters{"a": 1, parameters.TemplatesKey: map[string]interface{}{"x": "([[ . ]])"}}),
+				WithDelim("[[", "]]"),
+				WithExtraFunctions(),
+			)
+			result, err := r.Clone().Render(`[[ define "y" ]]<[[ . ]]>[[ end ]][[ toYaml .a ]][[ template "y" .a ]][[ template "x" .a ]][[ if eq .a 1 ]]ok[[ end ]]`)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "1\n<1>(1)ok", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "only the first reported by default",
+		f: func(tt Test) {
+			r := New(WithParameters(parameters.Parameters{"a": 1}), WithExtraFunctions())
+			_, err := r.Render(`{{ .a | whisper }} {{ shout .a }} {{ toYaml .a }}`)
+
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), `function "whisper" not defined`, tt.name)
+		},
+	})
+}
+
+func TestRenderSandboxed(t *testing.T) {
+	params := parameters.Parameters{"name": "render"}
+
+	Run(t, Test{
+		name: "safe function",
+		f: func(tt Test) {
+			result, err := RenderSandboxed(`{{ .name | upper | quote }}`, params)
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, `"RENDER"`, result, tt.name)
+		},
+	})
+
+	for _, disallowed := range []string{`{{ readFile "/etc/passwd" }}`, `{{ env "HOME" }}`, `{{ render "x" }}`} {
+		Run(t, Test{
+			name: "disallowed function " + disallowed,
+			f: func(tt Test) {
+				_, err := RenderSandboxed(disallowed, params)
+
+				assert.Error(t, err, tt.name)
+				assert.Contains(t, err.Error(), "not defined", tt.name)
+			},
+		})
+	}
+
+	Run(t, Test{
+		name: "all functions exist",
+		f: func(tt Test) {
+			for name, fn := range SandboxFunctions() {
+				assert.NotNil(t, fn, name)
+			}
+		},
+	})
+
+	Run(t, Test{
+		name: "bounded repeat",
+		f: func(tt Test) {
+			result, err := RenderSandboxed(`{{ repeat 3 .name }}`, params)
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "renderrenderrender", result, tt.name)
+
+			result, err = RenderSandboxed(`{{ repeat 1048576 "x" | len }}`, params)
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "1048576", result, tt.name)
+
+			_, err = RenderSandboxed(`{{ repeat 1048577 "x" }}`, params)
+			assert.Error(t, err, tt.name)
+
+			_, err = RenderSandboxed(`{{ repeat 1000000000 .name }}`, params)
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "repeat result is too long, the maximum length is 1048576", tt.name)
+
+			_, err = RenderSandboxed(`{{ repeat -1 .name }}`, params)
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "repeat expects a non-negative count, got: -1", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "bounded indent and nindent",
+		f: func(tt Test) {
+			result, err := RenderSandboxed(`{{ "a\nb" | indent 2 }}|{{ "a" | nindent 1 }}`, params)
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "  a\n  b|\n a", result, tt.name)
+
+			result, err = RenderSandboxed(`{{ indent 1048575 "x" | len }}`, params)
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "1048576", result, tt.name)
+
+			for _, tmpl := range []string{`{{ indent 1048576 "x" }}`, `{{ nindent 1000000000 "x" }}`, `{{ indent 600000 "a\nb" }}`} {
+				_, err = RenderSandboxed(tmpl, params)
+				assert.Error(t, err, tt.name)
+				assert.Contains(t, err.Error(), "indent result is too long, the maximum length is 1048576", tt.name)
+			}
+
+			_, err = RenderSandboxed(`{{ indent -1 "x" }}`, params)
+			assert.Error(t, err, tt.name)
+			assert.Contains(t, err.Error(), "indent expects a non-negative number of spaces, got: -1", tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "bounded n",
+		f: func(tt Test) {
+			result, err := RenderSandboxed(`{{ range n 1 3 }}{{ . }}{{ end }}|{{ n 0 1048575 | len }}|{{ n 3 1 | len }}`, params)
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "123|1048576|0", result, tt.name)
+
+			for _, tmpl := range []string{`{{ n 0 1000000000 }}`, `{{ n -9223372036854775808 9223372036854775807 }}`} {
+				_, err = RenderSandboxed(tmpl, params)
+				assert.Error(t, err, tt.name)
+				assert.Contains(t, err.Error(), "n result is too long, the maximum length is 1048576", tt.name)
+			}
+		},
+	})
+}
+
+func TestRenderWithClock(t *testing.T) {
+	clock := func() time.Time {
+		return time.Date(2020, time.March, 14, 15, 9, 26, 0, time.UTC)
+	}
+
+	Run(t, Test{
+		name: "date",
+		f: func(tt Test) {
+			result, err := RenderWithClock(`{{ now | date "2006-01-02T15:04:05Z07:00" }}`, parameters.Parameters{}, clock, rand.New(rand.NewSource(1)))
+
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, "2020-03-14T15:09:26Z", result, tt.name)
+		},
+	})
+
+	Run(t, Test{
+		name: "seeded uuidv4",
+		f: func(tt Test) {
+			first, err := RenderWithClock(`{{ uuidv4 }} {{ uuidv4 }}`, parameters.Parameters{}, clock, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err, tt.name)
+			second, err := RenderWithClock(`{{ uuidv4 }} {{ uuidv4 }}`, parameters.Parameters{}, clock, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err, tt.name)
+
+			assert.Equal(t, first, second, tt.name)
+			ids := strings.Split(first, " ")
+			assert.Len(t, ids, 2, tt.name)
+			assert.NotEqual(t, ids[0], ids[1], tt.name)
+			assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, ids[0], tt.name)
+		},
+	})
+}
+
 func Run(t *testing.T, tt Test) {
 	logrus.SetLevel(logrus.DebugLevel)
 	hook := test.NewGlobal()
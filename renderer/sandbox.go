@@ -0,0 +1,110 @@
+package renderer
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/VirtusLab/render/renderer/parameters"
+
+	"github.com/Masterminds/sprig/v3"
+	base "github.com/VirtusLab/go-extended/pkg/renderer"
+	"github.com/pkg/errors"
+)
+
+// MaxSandboxLength is the maximum length of a single result of the 'repeat', 'indent', 'nindent' and 'n'
+// functions in RenderSandboxed, it doesn't limit the whole output, see RenderBounded
+const MaxSandboxLength = 1 << 20
+
+// sandboxSprigFunctions are the pure data Sprig functions available in RenderSandboxed
+var sandboxSprigFunctions = []string{
+	// strings
+	"trim", "trimPrefix", "trimSuffix", "upper", "lower", "title", "replace", "substr", "trunc",
+	"contains", "hasPrefix", "hasSuffix", "quote", "squote", "cat", "join", "split", "splitList",
+	// defaults and conditions
+	"default", "empty", "coalesce", "ternary",
+	// lists and dictionaries
+	"list", "first", "last", "has", "uniq", "sortAlpha", "dict", "get", "hasKey", "keys", "pick", "omit",
+	// math and conversions
+	"add", "sub", "mul", "div", "mod", "max", "min", "toString", "int", "int64", "float64", "toJson",
+	// encoding
+	"b64enc", "b64dec",
+}
+
+// SandboxFunctions returns the template functions available in RenderSandboxed, only the ones without any I/O,
+// environment access or randomness: the Sprig string ('trim', 'trimPrefix', 'trimSuffix', 'upper', 'lower', 'title',
+// 'replace', 'repeat', 'substr', 'trunc', 'contains', 'hasPrefix', 'hasSuffix', 'quote', 'squote', 'cat', 'indent',
+// 'nindent', 'join', 'split', 'splitList'), default ('default', 'empty', 'coalesce', 'ternary'), list and dictionary
+// ('list', 'first', 'last', 'has', 'uniq', 'sortAlpha', 'dict', 'get', 'hasKey', 'keys', 'pick', 'omit'),
+// math and conversion ('add', 'sub', 'mul', 'div', 'mod', 'max', 'min', 'toString', 'int', 'int64', 'float64', 'toJson')
+// and encoding ('b64enc', 'b64dec') functions, and the custom 'n', 'required', 'toYaml', 'fromYaml', 'fromJson'
+// and 'jsonPath' functions, the 'repeat', 'indent', 'nindent' and 'n' results are limited by the MaxSandboxLength
+func SandboxFunctions() template.FuncMap {
+	sprigFunctions := sprig.TxtFuncMap()
+	functions := template.FuncMap{
+		"n":        boundedN,
+		"required": Required,
+		"toYaml":   ToYAML,
+		"fromYaml": FromYAML,
+		"fromJson": FromJSON,
+		"jsonPath": JSONPath,
+		"repeat":   boundedRepeat,
+		"indent":   boundedIndent,
+		"nindent":  boundedNindent,
+	}
+	for _, name := range sandboxSprigFunctions {
+		functions[name] = sprigFunctions[name]
+	}
+	return functions
+}
+
+// boundedRepeat is the Sprig 'repeat' returning an error instead of a string longer than the MaxSandboxLength
+func boundedRepeat(count int, str string) (string, error) {
+	if count < 0 {
+		return "", errors.Errorf("repeat expects a non-negative count, got: %d", count)
+	}
+	if count > 0 && len(str) > MaxSandboxLength/count {
+		return "", errors.Errorf("repeat result is too long, the maximum length is %d", MaxSandboxLength)
+	}
+	return strings.Repeat(str, count), nil
+}
+
+// boundedIndent is the Sprig 'indent' returning an error instead of a string longer than the MaxSandboxLength
+func boundedIndent(spaces int, v string) (string, error) {
+	if spaces < 0 {
+		return "", errors.Errorf("indent expects a non-negative number of spaces, got: %d", spaces)
+	}
+	lines := strings.Count(v, "\n") + 1
+	if len(v) > MaxSandboxLength || spaces > (MaxSandboxLength-len(v))/lines {
+		return "", errors.Errorf("indent result is too long, the maximum length is %d", MaxSandboxLength)
+	}
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(v, "\n", "\n"+pad, -1), nil
+}
+
+// boundedNindent is the Sprig 'nindent', the same as boundedIndent with a leading new line
+func boundedNindent(spaces int, v string) (string, error) {
+	indented, err := boundedIndent(spaces, v)
+	if err != nil {
+		return "", err
+	}
+	return "\n" + indented, nil
+}
+
+// boundedN is N returning an error instead of a slice longer than the MaxSandboxLength
+func boundedN(start, end int) ([]int, error) {
+	if end >= start && uint64(end)-uint64(start) >= MaxSandboxLength {
+		return nil, errors.Errorf("n result is too long, the maximum length is %d", MaxSandboxLength)
+	}
+	return N(start, end), nil
+}
+
+// RenderSandboxed renders an untrusted template with the parameters and only the SandboxFunctions,
+// the functions reading or writing files, rendering other templates, accessing the environment
+// or the network are not available, using them is a parse error
+func RenderSandboxed(tmpl string, params parameters.Parameters) (string, error) {
+	r := base.New(
+		WithParameters(params),
+		WithFunctions(SandboxFunctions()),
+	)
+	return r.NamedRender("sandboxed", tmpl)
+}